@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Exit codes shared by every command, so lab scripts can branch on them.
+const (
+	exitOK       = 0
+	exitFailure  = 1 // the command ran but failed (bad input, I/O, network)
+	exitUsage    = 2 // unknown command or invalid flags
+	exitWarnings = 3 // check: detection warnings at or above --fail-on
+)
+
+// command is one scanner subcommand. run receives the arguments after the
+// command name and returns the process exit code.
+type command struct {
+	name    string
+	summary string
+	usage   string
+	run     func(args []string) int
+}
+
+// commands is filled in init to break the cycle with runHelp.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"scan", "Detect hardware and print the specs and DINAU code", "scan [flags]", runScan},
+		{"open", "Detect hardware and open the website with the specs imported", "open [flags]", runOpen},
+		{"check", "Detect hardware and exit non-zero if detection had problems", "check [flags]", runCheck},
+		{"compare", "Show specs from scans, files, codes and links side by side", "compare [flags] source source...", runCompare},
+		{"history", "List saved scans and what changed between them", "history [flags]", runHistory},
+		{"encode", "Build a DINAU code or site link from hand-written specs", "encode [flags] [file]", runEncode},
+		{"decode", "Print the specs inside a DINAU code or ?specs= link", "decode [flags] [code or URL]", runDecode},
+		{"verify", "Check the signature of a DINAU code from a signing scanner build", "verify [flags] [code or URL]", runVerify},
+		{"keygen", "Generate a key pair for signed scanner builds", "keygen", runKeygen},
+		{"serve", "Serve the detected specs over HTTP on localhost", "serve [flags]", runServe},
+		{"doctor", "Check helper tools, config and website reachability", "doctor [flags]", runDoctor},
+		{"version", "Print the scanner version", "version", runVersion},
+		{"help", "Show help for a command", "help [command]", runHelp},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// runCLI dispatches args (without the program name) to a subcommand. The
+// legacy --terminal, --capture and --replay flags keep working.
+func runCLI(args []string) int {
+	switch {
+	case len(args) == 0:
+		// Double-click launches have no arguments
+		runGUI()
+		return exitOK
+	case args[0] == "--terminal":
+		runTerminal()
+		return exitOK
+	case args[0] == "--capture" && len(args) > 1:
+		return runScan([]string{"--capture", args[1]})
+	case args[0] == "--replay" && len(args) > 1:
+		return runReplay(args[1])
+	case args[0] == "-h" || args[0] == "--help":
+		return runHelp(nil)
+	case args[0] == "-v" || args[0] == "--version":
+		return runVersion(nil)
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printUsage(os.Stderr)
+		return exitUsage
+	}
+	return cmd.run(args[1:])
+}
+
+// newFlagSet returns a flag set whose -h output includes the command's usage
+// line and summary.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		cmd, _ := findCommand(name)
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: DoINeedAnUpgrade %s\n\n%s.\n", cmd.usage, cmd.summary)
+		if hasFlags(fs) {
+			fmt.Fprintln(out, "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// parseFlags parses args and maps flag errors to exit codes. ok is false
+// when the command should return code straight away.
+func parseFlags(fs *flag.FlagSet, args []string) (code int, ok bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: DoINeedAnUpgrade [global flags] <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command the scanner opens its GUI, as when double-clicked.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags:")
+	fmt.Fprintln(w, "  --base-url URL      website origin to use")
+	fmt.Fprintln(w, "  --redact FIELD=MODE redact fields before sending (e.g. os=family,storage=bucket)")
+	fmt.Fprintln(w, "  --preview           show the payload and ask before sending")
+	fmt.Fprintln(w, "  --sign-key KEY      sign payloads with this ed25519 key (base64 or file)")
+	fmt.Fprintln(w, "  --no-history        do not save scans to the local history, also in the GUI")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'DoINeedAnUpgrade help <command>' for the flags of a command.")
+}
+
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok || cmd.name == "help" {
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+			printUsage(os.Stderr)
+			return exitUsage
+		}
+		printUsage(os.Stdout)
+		return exitOK
+	}
+	return cmd.run([]string{"-h"})
+}
+
+// scanFlags are the detection flags shared by scan, open, check and serve.
+type scanFlags struct {
+	replay       string
+	capture      string
+	probeTimeout time.Duration
+	timeout      time.Duration
+	disable      string
+	gpu          string
+	noHistory    bool
+
+	// previous is the last saved scan before this one, set by detect.
+	previous *historyEntry
+}
+
+func (f *scanFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.replay, "replay", "", "detect from a snapshot archive or fixture directory instead of this machine")
+	fs.StringVar(&f.capture, "capture", "", "also save everything the detectors read to this snapshot archive (Linux only)")
+	fs.DurationVar(&f.probeTimeout, "probe-timeout", defaultDetectOptions.ProbeTimeout, "time limit for a single detector")
+	fs.DurationVar(&f.timeout, "timeout", defaultDetectOptions.Timeout, "time limit for the whole scan")
+	fs.StringVar(&f.disable, "disable", "", "comma-separated detector names to skip (e.g. lspci,df)")
+	fs.StringVar(&f.gpu, "gpu", defaultDetectOptions.GPU, "GPU to report when there are several: discrete, integrated, boot, a PCI slot or part of the name")
+	fs.BoolVar(&f.noHistory, "no-history", false, "do not save this scan to the local scan history")
+}
+
+func (f *scanFlags) disabled() []string {
+	var names []string
+	for _, name := range strings.Split(f.disable, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// detect runs the detectors selected by the flags. Scans of this machine are
+// saved to the history unless --no-history is set. Only the commands that scan
+// at the user's request (scan, open, check) use it; serve and compare call
+// run, which never records.
+func (f *scanFlags) detect() (DetectionResult, error) {
+	result, err := f.run()
+	if err == nil && f.replay == "" && !f.noHistory {
+		f.previous = recordScan(result)
+	}
+	return result, err
+}
+
+func (f *scanFlags) run() (DetectionResult, error) {
+	opts := DetectOptions{ProbeTimeout: f.probeTimeout, Timeout: f.timeout, GPU: f.gpu}
+	if f.replay != "" && f.capture != "" {
+		return DetectionResult{}, errors.New("--replay and --capture cannot be combined")
+	}
+
+	// Work on a copy so --disable does not leak into later scans in this
+	// process (serve, GUI rescans)
+	registry := defaultRegistry.Clone()
+	switch {
+	case f.capture != "":
+		if runtime.GOOS != "linux" {
+			return DetectionResult{}, errors.New("snapshot capture is only supported on Linux")
+		}
+		result, err := captureSnapshot(f.capture, opts, f.disabled())
+		if err != nil {
+			return result, fmt.Errorf("could not write snapshot: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Snapshot written to %s\n", f.capture)
+		result.live = true
+		return result, nil
+	case f.replay != "":
+		env, err := snapshotEnv(f.replay)
+		if err != nil {
+			return DetectionResult{}, err
+		}
+		registry = NewRegistry(linuxDetectors(env)...)
+	}
+	for _, name := range f.disabled() {
+		registry.Disable(name)
+	}
+	result := registry.Run(context.Background(), opts)
+	result.live = f.replay == ""
+	return result, nil
+}
+
+// runScan detects hardware and prints the specs without waiting for input.
+// The text format also prints the DINAU code.
+func runScan(args []string) int {
+	fs := newFlagSet("scan")
+	var sf scanFlags
+	sf.register(fs)
+	format := fs.String("format", "text", "output format: "+formatNames())
+	report := fs.String("report", "", "also save a self-contained HTML report to this file")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	write, err := lookupFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	result, err := sf.detect()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if *report != "" {
+		if err := writeReport(*report, result); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write report: %v\n", err)
+			return exitFailure
+		}
+		fmt.Fprintf(os.Stderr, "Report written to %s\n", *report)
+	}
+	if err := write(os.Stdout, result); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if *format == "text" {
+		fmt.Println()
+		fmt.Println(encodeResult(result))
+		if sf.previous != nil {
+			fmt.Println()
+			printChanges(os.Stdout, sf.previous, result.Specs)
+		}
+	}
+	return exitOK
+}
+
+// runOpen is the scripted counterpart of the terminal mode: it detects,
+// copies the code and opens the website, then returns.
+func runOpen(args []string) int {
+	fs := newFlagSet("open")
+	var sf scanFlags
+	sf.register(fs)
+	noClipboard := fs.Bool("no-clipboard", false, "do not copy the DINAU code to the clipboard")
+	noUpload := fs.Bool("no-upload", false, "open a ?specs= link instead of uploading to /api/import")
+	noBrowser := fs.Bool("no-browser", false, "print the link instead of opening the browser")
+	showQR := fs.Bool("qr", false, "also print the link as a QR code to scan with a phone")
+	qrInvert := fs.Bool("qr-invert", false, "draw the QR code for terminals with a light background")
+	qrPNG := fs.String("qr-png", "", "write the link as a QR code PNG to this file")
+	yes := fs.Bool("yes", false, "send without asking when a preview is required (the payload is still printed)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	result, err := sf.detect()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	for _, e := range warningMessages(result.Warnings) {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", e)
+	}
+	printChanges(os.Stderr, sf.previous, result.Specs)
+	ask := askInTerminal
+	if *yes {
+		ask = func(preview string) bool {
+			fmt.Fprintf(os.Stderr, "Sending to %s:\n%s\n", baseURL, preview)
+			return true
+		}
+	}
+	if !confirmSend(result, ask) {
+		fmt.Fprintln(os.Stderr, "Cancelled, nothing was sent.")
+		return exitFailure
+	}
+	if !*noClipboard {
+		copyToClipboard(encodeResult(result))
+	}
+
+	link := getURL(result)
+	if !*noUpload {
+		link = shareURL(result)
+	}
+	if *showQR {
+		if err := printQR(os.Stdout, link, *qrInvert); err != nil {
+			fmt.Fprintf(os.Stderr, "could not draw QR code: %v\n", err)
+		}
+	}
+	if *qrPNG != "" {
+		if err := writeQRPNG(*qrPNG, link); err != nil {
+			fmt.Fprintf(os.Stderr, "could not write QR code: %v\n", err)
+			return exitFailure
+		}
+	}
+	fmt.Println(link)
+	if !*noBrowser {
+		openBrowser(link)
+	}
+	return exitOK
+}
+
+var severityRank = map[Severity]int{SeverityInfo: 0, SeverityWarning: 1, SeverityError: 2}
+
+// runCheck exits with exitWarnings when detection reported a warning at or
+// above --fail-on, for fleet health checks.
+func runCheck(args []string) int {
+	fs := newFlagSet("check")
+	var sf scanFlags
+	sf.register(fs)
+	failOn := fs.String("fail-on", string(SeverityWarning), "lowest severity that fails the check: info, warning or error")
+	quiet := fs.Bool("q", false, "print nothing, only set the exit code")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	threshold, ok := severityRank[Severity(*failOn)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "invalid --fail-on %q: want info, warning or error\n", *failOn)
+		return exitUsage
+	}
+
+	result, err := sf.detect()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	failed := 0
+	locale := userLocale()
+	for _, w := range result.Warnings {
+		if severityRank[w.Severity] < threshold {
+			continue
+		}
+		failed++
+		if !*quiet {
+			fmt.Printf("%-7s %-24s %s\n", w.Severity, w.Code, w.Message(locale))
+		}
+	}
+	if failed > 0 {
+		return exitWarnings
+	}
+	if !*quiet {
+		fmt.Println("OK: all fields detected")
+	}
+	return exitOK
+}
+
+// readInput reads the named file, or stdin when name is empty or "-".
+func readInput(name string) ([]byte, error) {
+	if name == "" || name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+func runVersion(args []string) int {
+	fs := newFlagSet("version")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	fmt.Printf("DoINeedAnUpgrade scanner %s (%s/%s, %s)\n", version, runtime.GOOS, runtime.GOARCH, runtime.Version())
+	fmt.Printf("Payload version: %d\n", payloadVersion)
+	fmt.Printf("GPU name rules: v%d\n", gpuNames.version)
+	fmt.Printf("Website: %s\n", baseURL)
+	return exitOK
+}
+
+// printResult writes the human-readable spec summary and any warnings.
+func printResult(w io.Writer, result DetectionResult) {
+	specs := result.Specs
+	fmt.Fprintf(w, "OS:      %s\n", specs.OS)
+	fmt.Fprintf(w, "CPU:     %s (%d cores @ %.1f GHz)\n", specs.CPU, specs.CPUCores, specs.CPUSpeedGHz)
+	fmt.Fprintf(w, "GPU:     %s\n", specs.GPU)
+	for _, g := range result.GPUs {
+		if !g.Primary {
+			kind := "integrated"
+			if g.Discrete {
+				kind = "discrete"
+			}
+			fmt.Fprintf(w, "         also found: %s (%s, %s)\n", g.Name, kind, g.Slot)
+		}
+	}
+	if result.VRAM != nil {
+		fmt.Fprintf(w, "VRAM:    %s\n", result.VRAM)
+	}
+	fmt.Fprintf(w, "RAM:     %d GB\n", specs.RAMGB)
+	fmt.Fprintf(w, "Storage: %d GB free\n", specs.StorageGB)
+	if len(specs.GuessedFields) > 0 {
+		fmt.Fprintf(w, "Estimated: %s (please double-check on the website)\n", strings.Join(specs.GuessedFields, ", "))
+	}
+
+	if len(result.Warnings) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Warnings:")
+		locale := userLocale()
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(w, "  - %s\n", warning.Message(locale))
+			if remedy := warning.Remedy(locale); remedy != "" {
+				fmt.Fprintf(w, "    %s\n", remedy)
+			}
+		}
+	} else if len(specs.Warnings) > 0 {
+		// Decoded codes only carry the warning codes
+		codes := append([]string(nil), specs.Warnings...)
+		sort.Strings(codes)
+		fmt.Fprintf(w, "Warnings: %s\n", strings.Join(codes, ", "))
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The ?specs= query parameter uses a compact form of the payload: JSON with
+// short keys, optionally raw-deflate compressed, in unpadded URL-safe base64.
+// A version tag in front tells the website which form it is:
+//
+//	c1.<base64url(json)>
+//	z1.<base64url(deflate(json))>
+//
+// Parameters without a tag are the legacy standard base64 of the full JSON.
+const (
+	compactTag    = "c1."
+	compressedTag = "z1."
+)
+
+// urlBudget is the longest site URL the scanner produces. It keeps links well
+// under browser limits and the lengths chat apps shorten or cut off.
+const urlBudget = 2000
+
+// compactKeys maps payload JSON keys to their short form.
+var compactKeys = map[string]string{
+	"os":             "o",
+	"cpu":            "c",
+	"cpuCores":       "n",
+	"cpuSpeedGHz":    "s",
+	"gpu":            "g",
+	"ramGB":          "r",
+	"storageGB":      "d",
+	"warnings":       "w",
+	"guessedFields":  "q",
+	"ramApproximate": "a",
+	"v":              "v",
+	"scanner":        "sv",
+	"sum":            "k",
+	"ext":            "x",
+}
+
+var ErrOverBudget = errors.New("specs do not fit in a URL")
+
+// compactMinVersion is the payload version the compact forms were introduced
+// with. Every compact parameter carries a version and a checksum.
+const compactMinVersion = 2
+
+// renameKeys returns the top-level object in data with keys renamed through
+// names. Unknown keys are kept as they are.
+func renameKeys(data []byte, names map[string]string) ([]byte, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	out := make(map[string]json.RawMessage, len(obj))
+	for k, v := range obj {
+		if short, ok := names[k]; ok {
+			k = short
+		}
+		out[k] = v
+	}
+	return json.Marshal(out)
+}
+
+func expandedKeys() map[string]string {
+	long := make(map[string]string, len(compactKeys))
+	for k, v := range compactKeys {
+		long[v] = k
+	}
+	return long
+}
+
+// compactParams returns the uncompressed and compressed parameter forms of a
+// payload.
+func compactParams(p Payload) (plain, compressed string, err error) {
+	jsonData, err := p.marshal()
+	if err != nil {
+		return "", "", err
+	}
+	short, err := renameKeys(jsonData, compactKeys)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", "", err
+	}
+	w.Write(short)
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+
+	plain = compactTag + base64.RawURLEncoding.EncodeToString(short)
+	compressed = compressedTag + base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	return plain, compressed, nil
+}
+
+// encodeSpecsParam picks the shortest ?specs= value whose URL (base plus
+// "?specs=") fits in budget. Extended sections are dropped first when the
+// payload is too long. If nothing fits, the shortest value is returned with
+// ErrOverBudget.
+func encodeSpecsParam(p Payload, base string, budget int) (string, error) {
+	candidates := []Payload{p}
+	if len(p.Ext) > 0 {
+		core := p
+		core.Ext = nil
+		candidates = append(candidates, core)
+	}
+
+	overhead := len(base) + len("?specs=")
+	shortest := ""
+	for _, c := range candidates {
+		plain, compressed, err := compactParams(c)
+		if err != nil {
+			return "", err
+		}
+		best := plain
+		if len(compressed) < len(plain) {
+			best = compressed
+		}
+		if overhead+len(best) <= budget {
+			return best, nil
+		}
+		if shortest == "" || len(best) < len(shortest) {
+			shortest = best
+		}
+	}
+	return shortest, fmt.Errorf("%w: %d characters over a %d budget", ErrOverBudget, overhead+len(shortest)-budget, budget)
+}
+
+// decodeSpecsParam parses a ?specs= value in any form the scanner has
+// produced.
+func decodeSpecsParam(param string) (Payload, error) {
+	param = strings.TrimSpace(param)
+	var compressed bool
+	switch {
+	case strings.HasPrefix(param, compactTag):
+		param = strings.TrimPrefix(param, compactTag)
+	case strings.HasPrefix(param, compressedTag):
+		param = strings.TrimPrefix(param, compressedTag)
+		compressed = true
+	default:
+		return decodePayload(payloadPrefix + param)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(param)
+	if err != nil {
+		return Payload{}, fmt.Errorf("invalid base64: %w", err)
+	}
+	if compressed {
+		data, err = io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(data)), 1<<20))
+		if err != nil {
+			return Payload{}, fmt.Errorf("invalid deflate data: %w", err)
+		}
+	}
+	long, err := renameKeys(data, expandedKeys())
+	if err != nil {
+		return Payload{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	p, err := parsePayloadJSON(long)
+	if err != nil {
+		return Payload{}, err
+	}
+	// Without a version the checksum is not checked, so a damaged parameter
+	// that lost "v" would otherwise pass as a v1 payload
+	if p.Version < compactMinVersion {
+		return Payload{}, fmt.Errorf("compact specs parameter has payload version %d, want %d or later", p.Version, compactMinVersion)
+	}
+	return p, nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// quickText is the alphabet for generated strings: ASCII, JSON escapes and
+// multi-byte runes, all valid UTF-8 so they survive a JSON round trip.
+var quickText = []rune(`abcXYZ019 .,-_/()[]"\` + "\t\néü™®Ω中文🙂")
+
+func quickString(r *rand.Rand, max int) string {
+	var b strings.Builder
+	for n := r.Intn(max + 1); n > 0; n-- {
+		b.WriteRune(quickText[r.Intn(len(quickText))])
+	}
+	return b.String()
+}
+
+func quickStrings(r *rand.Rand) []string {
+	var out []string
+	for n := r.Intn(4); n > 0; n-- {
+		out = append(out, quickString(r, 12))
+	}
+	return out
+}
+
+// randomPayload builds a payload with random specs and extended sections.
+func randomPayload(r *rand.Rand) Payload {
+	p := Payload{Specs: Specs{
+		OS:             quickString(r, 40),
+		CPU:            quickString(r, 60),
+		CPUCores:       r.Intn(256),
+		CPUSpeedGHz:    r.Float64() * 6,
+		GPU:            quickString(r, 60),
+		RAMGB:          r.Intn(2048),
+		StorageGB:      r.Intn(1 << 16),
+		Warnings:       quickStrings(r),
+		GuessedFields:  quickStrings(r),
+		RAMApproximate: r.Intn(2) == 0,
+	}}
+	for n := r.Intn(4); n > 0; n-- {
+		section := map[string]any{
+			quickString(r, 8): quickString(r, 30),
+			"n":               r.Intn(1 << 20),
+			"list":            quickStrings(r),
+		}
+		p.setExt(quickString(r, 10), section)
+	}
+	return p
+}
+
+func payloadValues(args []reflect.Value, r *rand.Rand) {
+	args[0] = reflect.ValueOf(randomPayload(r))
+	for i := 1; i < len(args); i++ {
+		args[i] = reflect.ValueOf(r.Int())
+	}
+}
+
+// samePayload compares the fields a payload carries through encoding.
+func samePayload(a, b Payload) bool {
+	if len(a.Ext) == 0 && len(b.Ext) == 0 {
+		a.Ext, b.Ext = nil, nil
+	}
+	return reflect.DeepEqual(a.Specs, b.Specs) && reflect.DeepEqual(a.Ext, b.Ext)
+}
+
+func TestSpecsParamRoundTrip(t *testing.T) {
+	roundTrip := func(p Payload) bool {
+		plain, compressed, err := compactParams(p)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		for _, param := range []string{plain, compressed} {
+			got, err := decodeSpecsParam(param)
+			if err != nil {
+				t.Logf("%s: %v", param, err)
+				return false
+			}
+			if got.Version != payloadVersion || !samePayload(got, p) {
+				t.Logf("%s decoded to %+v, want %+v", param, got, p)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 500, Values: payloadValues}); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestSpecsParamCorruption changes one character of an encoded parameter:
+// decoding must then fail, or give back the original payload when the change
+// only touched base64 padding bits. A change that turns "v" into a newer
+// version is the exception: newer payloads may have fields this decoder does
+// not know, so their checksum cannot be checked and they decode unverified.
+func TestSpecsParamCorruption(t *testing.T) {
+	alphabet := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	corrupt := func(p Payload, pos, char int) bool {
+		plain, compressed, err := compactParams(p)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		for _, param := range []string{plain, compressed} {
+			body := []byte(param[len(compactTag):])
+			i := pos % len(body)
+			c := alphabet[char%len(alphabet)]
+			if body[i] == c {
+				c = alphabet[(char+1)%len(alphabet)]
+			}
+			body[i] = c
+			changed := param[:len(compactTag)] + string(body)
+			if got, err := decodeSpecsParam(changed); err == nil && got.Version == payloadVersion && !samePayload(got, p) {
+				t.Logf("%s was accepted as %+v", changed, got)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(corrupt, &quick.Config{MaxCount: 500, Values: payloadValues}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSpecsParamChecksum(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		p := randomPayload(r)
+		plain, _, err := compactParams(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(plain, compactTag))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+
+		edits := map[string]func(){
+			"ram changed":  func() { fields["r"] = float64(p.RAMGB + 1) },
+			"sum changed":  func() { fields["k"] = "00000000" },
+			"gpu changed":  func() { fields["g"] = p.GPU + "x" },
+			"ext replaced": func() { fields["x"] = map[string]any{"other": 1} },
+			"version gone": func() { delete(fields, "v") },
+		}
+		for name, edit := range edits {
+			saved := make(map[string]any, len(fields))
+			for k, v := range fields {
+				saved[k] = v
+			}
+			edit()
+			tampered, _ := json.Marshal(fields)
+			fields = saved
+
+			param := compactTag + base64.RawURLEncoding.EncodeToString(tampered)
+			_, err := decodeSpecsParam(param)
+			if name == "version gone" {
+				if err == nil {
+					t.Errorf("%s: accepted, want an error", name)
+				}
+				continue
+			}
+			if !errors.Is(err, ErrChecksumMismatch) {
+				t.Errorf("%s: err = %v, want %v", name, err, ErrChecksumMismatch)
+			}
+		}
+	}
+}
+
+func TestSpecsParamTags(t *testing.T) {
+	p := randomPayload(rand.New(rand.NewSource(2)))
+	plain, compressed, err := compactParams(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plainBody := strings.TrimPrefix(plain, compactTag)
+	compressedBody := strings.TrimPrefix(compressed, compressedTag)
+	for _, param := range []string{
+		compressedTag + plainBody, // JSON read as deflate data
+		compactTag + compressedBody,
+		"c2." + plainBody,
+		"z2." + compressedBody,
+		"C1." + plainBody,
+		plainBody,
+	} {
+		if got, err := decodeSpecsParam(param); err == nil {
+			t.Errorf("decodeSpecsParam(%.12s...) = %+v, want an error", param, got)
+		}
+	}
+}
+
+func TestSpecsParamBudget(t *testing.T) {
+	const base = "https://example.com"
+	r := rand.New(rand.NewSource(3))
+	noise := make([]byte, 3000)
+	r.Read(noise)
+
+	p := Payload{Specs: testImportSpecs}
+	p.setExt("noise", base64.StdEncoding.EncodeToString(noise))
+	full, _, err := compactParams(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(base)+len("?specs=")+len(full) <= urlBudget {
+		t.Fatalf("test payload fits in %d characters without dropping ext", urlBudget)
+	}
+
+	param, err := encodeSpecsParam(p, base, urlBudget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link := base + "?specs=" + param; len(link) > urlBudget {
+		t.Errorf("link is %d characters, over the %d budget", len(link), urlBudget)
+	}
+	got, err := decodeSpecsParam(param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Specs, p.Specs) || len(got.Ext) != 0 {
+		t.Errorf("decoded %+v with ext %v, want the specs without ext", got.Specs, got.Ext)
+	}
+
+	huge := Payload{Specs: testImportSpecs}
+	huge.Specs.OS = base64.StdEncoding.EncodeToString(noise)
+	if _, err := encodeSpecsParam(huge, base, urlBudget); !errors.Is(err, ErrOverBudget) {
+		t.Errorf("oversized specs: err = %v, want %v", err, ErrOverBudget)
+	}
+}
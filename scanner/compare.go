@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// compareSource is one column of a comparison.
+type compareSource struct {
+	label string
+	specs Specs
+}
+
+// loadCompareSource resolves one compare argument: "scan" for this machine,
+// a Specs JSON/YAML file or a file holding a code, or a DINAU code or link.
+func loadCompareSource(arg string, sf *scanFlags) (compareSource, error) {
+	if arg == "scan" {
+		// A comparison is not a scan the user asked to keep
+		result, err := sf.run()
+		return compareSource{label: "this machine", specs: result.Specs}, err
+	}
+	if info, err := os.Stat(arg); (err == nil && !info.IsDir()) || arg == "-" {
+		data, err := readInput(arg)
+		if err != nil {
+			return compareSource{}, err
+		}
+		label := filepath.Base(arg)
+		if arg == "-" {
+			label = "stdin"
+		}
+		text := strings.TrimSpace(string(data))
+		ext := strings.ToLower(filepath.Ext(arg))
+		if strings.HasPrefix(text, "{") || ext == ".json" || ext == ".yaml" || ext == ".yml" {
+			fields, err := parseSpecsDocument(data, !strings.HasPrefix(text, "{"))
+			if err != nil {
+				return compareSource{}, fmt.Errorf("%s: %w", arg, err)
+			}
+			specs, err := specsFromFields(fields)
+			if err != nil {
+				return compareSource{}, fmt.Errorf("%s: %w", arg, err)
+			}
+			return compareSource{label: label, specs: specs}, nil
+		}
+		p, err := payloadFromInput(text)
+		if err != nil {
+			return compareSource{}, fmt.Errorf("%s: %w", arg, err)
+		}
+		return compareSource{label: label, specs: p.Specs}, nil
+	}
+	p, err := payloadFromInput(arg)
+	if err != nil {
+		return compareSource{}, err
+	}
+	label := "code"
+	if strings.Contains(arg, "://") || strings.HasPrefix(arg, "?") {
+		label = "link"
+	}
+	return compareSource{label: label, specs: p.Specs}, nil
+}
+
+// compareTable builds the rows of a comparison. Columns after the first show
+// their difference to the first one.
+func compareTable(sources []compareSource) (header []string, rows [][]string, matches []string) {
+	header = []string{""}
+	for _, s := range sources {
+		header = append(header, s.label)
+	}
+
+	text := func(label string, value func(Specs) string) {
+		row := []string{label}
+		for _, s := range sources {
+			row = append(row, orDash(value(s.specs)))
+		}
+		rows = append(rows, row)
+	}
+	number := func(label, unit string, value func(Specs) float64, percent bool) {
+		row := []string{label}
+		base := value(sources[0].specs)
+		for i, s := range sources {
+			v := value(s.specs)
+			if v == 0 {
+				row = append(row, "—")
+				continue
+			}
+			cell := strconv.FormatFloat(v, 'f', -1, 64) + unit
+			switch {
+			case i == 0 || base == 0 || v == base:
+			case percent:
+				cell += fmt.Sprintf(" (%+.0f%%)", (v/base-1)*100)
+			default:
+				sign := ""
+				if v > base {
+					sign = "+"
+				}
+				cell += " (" + sign + strconv.FormatFloat(math.Round((v-base)*10)/10, 'f', -1, 64) + ")"
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+	score := func(table scoreTable, name func(Specs) string) func(Specs) float64 {
+		return func(s Specs) float64 {
+			match, score, ok := table.lookup(name(s))
+			if !ok {
+				return 0
+			}
+			if match == name(s) {
+				return score
+			}
+			note := fmt.Sprintf("%s → %s", name(s), match)
+			for _, m := range matches {
+				if m == note {
+					return score
+				}
+			}
+			matches = append(matches, note)
+			return score
+		}
+	}
+
+	text("OS", func(s Specs) string { return s.OS })
+	text("CPU", func(s Specs) string { return s.CPU })
+	number("CPU cores", "", func(s Specs) float64 { return float64(s.CPUCores) }, false)
+	number("CPU speed", " GHz", func(s Specs) float64 { return s.CPUSpeedGHz }, false)
+	text("GPU", func(s Specs) string { return s.GPU })
+	number("RAM", " GB", func(s Specs) float64 { return float64(s.RAMGB) }, false)
+	number("Free storage", " GB", func(s Specs) float64 { return float64(s.StorageGB) }, false)
+	number("CPU score", "", score(cpuScores, func(s Specs) string { return s.CPU }), true)
+	number("GPU score", "", score(gpuScores, func(s Specs) string { return s.GPU }), true)
+	return header, rows, matches
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+func writeTextTable(w io.Writer, header []string, rows [][]string) {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	line := func(row []string) {
+		for i, cell := range row {
+			if i < len(row)-1 {
+				cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2)
+			}
+			fmt.Fprint(w, cell)
+		}
+		fmt.Fprintln(w)
+	}
+	line(header)
+	for _, row := range rows {
+		line(row)
+	}
+}
+
+func writeMarkdownTable(w io.Writer, header []string, rows [][]string) {
+	line := func(row []string) {
+		fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+	}
+	line(header)
+	sep := make([]string, len(header))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	line(sep)
+	for _, row := range rows {
+		line(row)
+	}
+}
+
+// runCompare prints the specs of several sources side by side.
+func runCompare(args []string) int {
+	fs := newFlagSet("compare")
+	var sf scanFlags
+	sf.register(fs)
+	format := fs.String("format", "text", "output format: text or markdown")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *format != "text" && *format != "markdown" && *format != "md" {
+		fmt.Fprintf(os.Stderr, "invalid --format %q: want text or markdown\n", *format)
+		return exitUsage
+	}
+	if fs.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, "compare needs at least two sources: scan, a specs file, a DINAU code or a site link")
+		return exitUsage
+	}
+
+	var sources []compareSource
+	for _, arg := range fs.Args() {
+		source, err := loadCompareSource(arg, &sf)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not read %s: %v\n", arg, err)
+			return exitFailure
+		}
+		sources = append(sources, source)
+	}
+	// Number repeated labels so columns can be told apart
+	seen := make(map[string]int)
+	for _, s := range sources {
+		seen[s.label]++
+	}
+	count := make(map[string]int)
+	for i, s := range sources {
+		if seen[s.label] > 1 {
+			count[s.label]++
+			sources[i].label = fmt.Sprintf("%s %d", s.label, count[s.label])
+		}
+	}
+
+	header, rows, matches := compareTable(sources)
+	if *format == "text" {
+		writeTextTable(os.Stdout, header, rows)
+	} else {
+		writeMarkdownTable(os.Stdout, header, rows)
+	}
+	if len(matches) > 0 {
+		fmt.Println()
+		fmt.Println("Scores come from the website's offline table, matched by name:")
+		for _, m := range matches {
+			fmt.Printf("  %s\n", m)
+		}
+	}
+	return exitOK
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareTable(t *testing.T) {
+	sources := []compareSource{
+		{label: "this machine", specs: Specs{OS: "Windows 11", CPU: "AMD Ryzen 5 3600", CPUCores: 6, CPUSpeedGHz: 3.6, GPU: "NVIDIA GeForce GTX 1060 6GB", RAMGB: 16, StorageGB: 250}},
+		{label: "code", specs: Specs{OS: "Windows 11", CPU: "AMD Ryzen 7 5800X 8-Core Processor", CPUCores: 8, CPUSpeedGHz: 3.8, GPU: "NVIDIA GeForce RTX 3070", RAMGB: 32}},
+		{label: "specs.json", specs: Specs{OS: "Windows 10", CPU: "Intel Core i5-12400F", CPUCores: 6, CPUSpeedGHz: 2.5, RAMGB: 8, StorageGB: 500}},
+	}
+	header, rows, matches := compareTable(sources)
+
+	if want := []string{"", "this machine", "code", "specs.json"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("header %q, want %q", header, want)
+	}
+	want := [][]string{
+		{"OS", "Windows 11", "Windows 11", "Windows 10"},
+		{"CPU", "AMD Ryzen 5 3600", "AMD Ryzen 7 5800X 8-Core Processor", "Intel Core i5-12400F"},
+		{"CPU cores", "6", "8 (+2)", "6"},
+		{"CPU speed", "3.6 GHz", "3.8 GHz (+0.2)", "2.5 GHz (-1.1)"},
+		{"GPU", "NVIDIA GeForce GTX 1060 6GB", "NVIDIA GeForce RTX 3070", "—"},
+		{"RAM", "16 GB", "32 GB (+16)", "8 GB (-8)"},
+		{"Free storage", "250 GB", "—", "500 GB (+250)"},
+		{"CPU score", "45", "70 (+56%)", "55 (+22%)"},
+		{"GPU score", "28", "55 (+96%)", "—"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("%d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(rows[i], want[i]) {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+	if want := []string{"AMD Ryzen 7 5800X 8-Core Processor → AMD Ryzen 7 5800X"}; !reflect.DeepEqual(matches, want) {
+		t.Errorf("matches %q, want %q", matches, want)
+	}
+}
+
+// TestCompareTableUnknownBase checks that nothing is compared against a
+// first column without a value.
+func TestCompareTableUnknownBase(t *testing.T) {
+	sources := []compareSource{
+		{label: "a", specs: Specs{CPU: "Unknown CPU"}},
+		{label: "b", specs: Specs{CPU: "AMD Ryzen 5 3600", RAMGB: 16}},
+	}
+	_, rows, _ := compareTable(sources)
+	for _, row := range rows {
+		switch row[0] {
+		case "RAM":
+			if row[2] != "16 GB" {
+				t.Errorf("RAM against a missing base = %q, want no difference", row[2])
+			}
+		case "CPU score":
+			if row[1] != "—" || row[2] != "45" {
+				t.Errorf("CPU score %q, want — and 45 without a difference", row[1:])
+			}
+		}
+	}
+}
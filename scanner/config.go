@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultBaseURL is the site the scanner talks to when nothing else is
+// configured. Self-hosted builds can change it with
+// -ldflags "-X main.defaultBaseURL=https://example.com".
+var defaultBaseURL = "https://do-i-need-to-upgrade.vercel.app"
+
+// baseURL is the site origin in use, resolved once at startup.
+var baseURL = defaultBaseURL
+
+// Config is the optional config file, by default
+// <user config dir>/doineedanupgrade/config.json.
+type Config struct {
+	BaseURL string `json:"baseURL,omitempty"`
+	// Redact maps Specs fields to a redaction mode, e.g. {"os": "family"}.
+	Redact map[string]string `json:"redact,omitempty"`
+	// Preview asks for approval of the payload before it is sent.
+	Preview bool `json:"preview,omitempty"`
+	// NoHistory stops scans from being saved to the local scan history.
+	NoHistory bool `json:"noHistory,omitempty"`
+	// GPU picks the reported GPU when several are found: "discrete",
+	// "integrated", "boot", a PCI slot or part of the name.
+	GPU string `json:"gpu,omitempty"`
+}
+
+// configPath returns the config file location. DINAU_CONFIG overrides it.
+func configPath() string {
+	if p := os.Getenv("DINAU_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "doineedanupgrade", "config.json")
+}
+
+// loadConfig reads the config file. A missing file is an empty config.
+func loadConfig() (Config, error) {
+	var cfg Config
+	path := configPath()
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// resolveBaseURL picks the site origin from, in order of precedence, the
+// --base-url flag, the DINAU_BASE_URL environment variable, the config file
+// and the build-time default.
+func resolveBaseURL(flagValue string, cfg Config) (string, error) {
+	candidates := []struct {
+		source string
+		value  string
+	}{
+		{"--base-url", flagValue},
+		{"DINAU_BASE_URL", os.Getenv("DINAU_BASE_URL")},
+		{configPath(), cfg.BaseURL},
+		{"build default", defaultBaseURL},
+	}
+	for _, c := range candidates {
+		if c.value == "" {
+			continue
+		}
+		origin, err := validateBaseURL(c.value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", c.source, err)
+		}
+		return origin, nil
+	}
+	return "", fmt.Errorf("no base URL configured")
+}
+
+// validateBaseURL checks that raw is an http(s) origin such as
+// "http://localhost:3000" and returns it without a trailing slash.
+func validateBaseURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid base URL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q: missing host", raw)
+	}
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid base URL %q: must be an origin without path, query or credentials", raw)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ErrImportLink is returned for ?import= links: the token only points at
+// specs held by the website and is used up when the page opens it.
+var ErrImportLink = errors.New("?import= links are single-use tokens stored on the website; ask for the DINAU code or the ?specs= link instead")
+
+// payloadFromInput decodes what a user pasted: a DINAU code, a site URL with
+// ?specs=, or a bare ?specs= value.
+func payloadFromInput(input string) (Payload, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return Payload{}, errors.New("no code given")
+	}
+	if strings.HasPrefix(input, payloadPrefix) {
+		return decodePayload(input)
+	}
+	if strings.Contains(input, "://") || strings.HasPrefix(input, "?") {
+		return payloadFromURL(input)
+	}
+	if strings.HasPrefix(input, compactTag) || strings.HasPrefix(input, compressedTag) {
+		return decodeSpecsParam(input)
+	}
+	// Anything else can only be a legacy ?specs= value
+	p, err := decodeSpecsParam(input)
+	if err != nil {
+		return Payload{}, fmt.Errorf("not a DINAU code or site link: %w", err)
+	}
+	return p, nil
+}
+
+func payloadFromURL(raw string) (Payload, error) {
+	query := raw
+	if i := strings.Index(raw, "?"); i >= 0 {
+		query = raw[i+1:]
+	}
+	if i := strings.Index(query, "#"); i >= 0 {
+		query = query[:i]
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return Payload{}, fmt.Errorf("invalid link: %w", err)
+	}
+	if param := values.Get("specs"); param != "" {
+		// Legacy base64 may arrive with '+' unescaped, like the website handles
+		return decodeSpecsParam(strings.ReplaceAll(param, " ", "+"))
+	}
+	if values.Get("import") != "" {
+		return Payload{}, ErrImportLink
+	}
+	return Payload{}, errors.New("link has no ?specs= parameter")
+}
+
+// resultFromPayload rebuilds as much of the DetectionResult as the payload
+// carries. Malformed extended sections are skipped.
+func resultFromPayload(p Payload) DetectionResult {
+	result := DetectionResult{Specs: p.Specs}
+	var warnings []Warning
+	if ok, err := p.ext(extWarnings, &warnings); ok && err == nil {
+		result.Warnings = warnings
+	}
+	var provenance map[Component]Provenance
+	if ok, err := p.ext(extProvenance, &provenance); ok && err == nil {
+		result.Provenance = provenance
+	}
+	var gpus []GPU
+	if ok, err := p.ext(extGPUs, &gpus); ok && err == nil {
+		result.GPUs = gpus
+	}
+	var vram VRAM
+	if ok, err := p.ext(extVRAM, &vram); ok && err == nil {
+		result.VRAM = &vram
+	}
+	return result
+}
+
+// runDecode prints the specs inside a DINAU code or site link given as an
+// argument or on stdin.
+func runDecode(args []string) int {
+	fs := newFlagSet("decode")
+	format := fs.String("format", "text", "output format: "+formatNames())
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	write, err := lookupFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	var input string
+	if fs.NArg() > 0 && fs.Arg(0) != "-" {
+		input = fs.Arg(0)
+	} else {
+		data, err := readInput("")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		input = string(data)
+	}
+	p, err := payloadFromInput(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not decode: %v\n", err)
+		return exitFailure
+	}
+
+	result := resultFromPayload(p)
+	if err := write(os.Stdout, result); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if *format == "text" {
+		fmt.Println()
+		fmt.Printf("Payload version %d", p.Version)
+		if p.Scanner != "" {
+			fmt.Printf(", scanner %s", p.Scanner)
+		}
+		fmt.Println()
+	}
+	return exitOK
+}
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"regexp"
@@ -10,121 +11,173 @@ import (
 	"strings"
 )
 
-func detectSpecs() DetectionResult {
-	specs := Specs{}
-	var errors []string
+func platformDetectors() []Detector {
+	return []Detector{
+		newDetector("sw_vers", ComponentOS, detectSwVers),
+		newDetector("sysctl-brand", ComponentCPU, detectCPUBrand),
+		newDetector("sysctl-physicalcpu", ComponentCPUCores, detectPhysicalCPU),
+		newDetector("apple-silicon-speed", ComponentCPUSpeed, detectAppleSiliconSpeed),
+		newDetector("sysctl-cpufrequency", ComponentCPUSpeed, detectCPUFrequency),
+		newDetector("apple-silicon-gpu", ComponentGPU, detectAppleSiliconGPU),
+		newDetector("system_profiler", ComponentGPU, detectSystemProfilerGPU),
+		newDetector("sysctl-memsize", ComponentRAM, detectMemSize),
+		newDetector("df", ComponentStorage, detectDfStorage),
+	}
+}
 
-	// OS
-	productName, err := execCmd("sw_vers", "-productName")
+func detectSwVers(ctx context.Context) (Reading, error) {
+	var warnings []Warning
+	productName, err := execCmd(ctx, "sw_vers", "-productName")
 	if err != nil {
-		errors = append(errors, "Could not detect OS product name")
+		warnings = append(warnings, newWarning(CodeOSNameUndetected, SeverityWarning, ComponentOS).withCause(err))
 	}
-	productVersion, err := execCmd("sw_vers", "-productVersion")
+	productVersion, err := execCmd(ctx, "sw_vers", "-productVersion")
 	if err != nil {
-		errors = append(errors, "Could not detect OS version")
+		warnings = append(warnings, newWarning(CodeOSUndetected, SeverityWarning, ComponentOS).withCause(err))
+	}
+	osName := strings.TrimSpace(strings.TrimSpace(productName) + " " + strings.TrimSpace(productVersion))
+	if osName == "" {
+		return Reading{}, newWarning(CodeOSUndetected, SeverityError, ComponentOS)
 	}
-	specs.OS = strings.TrimSpace(productName) + " " + strings.TrimSpace(productVersion)
+	return Reading{Value: osName, Source: SourceCommand, Confidence: ConfidenceExact, Warnings: warnings}, nil
+}
 
-	// CPU
-	cpuBrand, err := execCmd("sysctl", "-n", "machdep.cpu.brand_string")
+func detectCPUBrand(ctx context.Context) (Reading, error) {
+	cpuBrand, err := execCmd(ctx, "sysctl", "-n", "machdep.cpu.brand_string")
 	if err != nil {
-		errors = append(errors, "Could not detect CPU brand")
+		return Reading{}, newWarning(CodeCPUNameUndetected, SeverityError, ComponentCPU).withCause(err)
 	}
-	specs.CPU = cleanCPUName(strings.TrimSpace(cpuBrand))
+	return Reading{Value: cleanCPUName(strings.TrimSpace(cpuBrand)), Source: SourceSysctl, Confidence: ConfidenceExact}, nil
+}
 
-	// CPU Cores
-	coresStr, err := execCmd("sysctl", "-n", "hw.physicalcpu")
+func detectPhysicalCPU(ctx context.Context) (Reading, error) {
+	coresStr, err := execCmd(ctx, "sysctl", "-n", "hw.physicalcpu")
 	if err != nil {
-		errors = append(errors, "Could not detect CPU core count")
+		return Reading{}, newWarning(CodeCPUCoresUndetected, SeverityError, ComponentCPUCores).withCause(err)
 	}
-	specs.CPUCores, _ = strconv.Atoi(strings.TrimSpace(coresStr))
+	cores, _ := strconv.Atoi(strings.TrimSpace(coresStr))
+	if cores <= 0 {
+		return Reading{}, newWarning(CodeCPUCoresUndetected, SeverityError, ComponentCPUCores)
+	}
+	return Reading{Value: cores, Source: SourceSysctl, Confidence: ConfidenceExact}, nil
+}
 
-	// CPU Speed
-	arch, err := execCmd("uname", "-m")
+// isAppleSilicon reports whether the machine is arm64.
+func isAppleSilicon(ctx context.Context) (bool, error) {
+	arch, err := execCmd(ctx, "uname", "-m")
 	if err != nil {
-		errors = append(errors, "Could not detect CPU architecture")
-	}
-	isAppleSilicon := strings.TrimSpace(arch) == "arm64"
-	if isAppleSilicon {
-		// Apple Silicon - use known max performance core speeds
-		speed, warning := getAppleSiliconSpeed(specs.CPU)
-		specs.CPUSpeedGHz = speed
-		if warning != "" {
-			errors = append(errors, warning)
-		}
-	} else {
-		// Intel Mac - get frequency from sysctl
-		freqStr, err := execCmd("sysctl", "-n", "hw.cpufrequency_max")
-		if err != nil {
-			errors = append(errors, "Could not detect CPU frequency")
-		} else {
-			if freqHz, err := strconv.ParseInt(strings.TrimSpace(freqStr), 10, 64); err == nil && freqHz > 0 {
-				specs.CPUSpeedGHz = float64(freqHz) / 1e9
-			}
-		}
+		return false, newWarning(CodeCPUArchUndetected, SeverityError, "").withCause(err)
 	}
+	return strings.TrimSpace(arch) == "arm64", nil
+}
 
-	// GPU - Apple Silicon uses chip name as GPU
-	if isAppleSilicon {
-		// Extract Apple chip name
-		re := regexp.MustCompile(`Apple M\d+\s*(Pro|Max|Ultra)?`)
-		match := re.FindString(specs.CPU)
-		if match != "" {
-			specs.GPU = strings.TrimSpace(match)
-		} else {
-			specs.GPU = "Apple Silicon GPU"
+// detectAppleSiliconSpeed uses known max performance core speeds.
+func detectAppleSiliconSpeed(ctx context.Context) (Reading, error) {
+	appleSilicon, err := isAppleSilicon(ctx)
+	if err != nil {
+		return Reading{}, err
+	}
+	if !appleSilicon {
+		return Reading{}, newWarning(CodeProbeNotApplicable, SeverityInfo, ComponentCPUSpeed).with("detector", "apple-silicon-speed")
+	}
+	cpuBrand, _ := execCmd(ctx, "sysctl", "-n", "machdep.cpu.brand_string")
+	speed, warning := getAppleSiliconSpeed(cleanCPUName(strings.TrimSpace(cpuBrand)))
+	reading := Reading{Value: speed, Source: SourceTable, Confidence: ConfidenceHigh}
+	if warning != nil {
+		reading.Warnings = []Warning{*warning}
+		reading.Confidence = ConfidenceEstimated
+		reading.Source = SourceFallback
+		if warning.Code == CodeCPUSpeedEstimated {
+			reading.Source = SourceExtrapolation
 		}
-	} else {
-		// Intel Mac - get discrete/integrated GPU
-		gpuInfo, err := execCmd("system_profiler", "SPDisplaysDataType")
-		if err != nil {
-			errors = append(errors, "Could not detect GPU information")
-		} else {
-			for _, line := range strings.Split(gpuInfo, "\n") {
-				if strings.Contains(line, "Chipset Model") {
-					parts := strings.SplitN(line, ":", 2)
-					if len(parts) == 2 {
-						specs.GPU = strings.TrimSpace(parts[1])
-						break
-					}
-				}
+	}
+	return reading, nil
+}
+
+// detectCPUFrequency reads the max frequency on Intel Macs.
+func detectCPUFrequency(ctx context.Context) (Reading, error) {
+	freqStr, err := execCmd(ctx, "sysctl", "-n", "hw.cpufrequency_max")
+	if err != nil {
+		return Reading{}, newWarning(CodeCPUSpeedUndetected, SeverityError, ComponentCPUSpeed).withCause(err)
+	}
+	if freqHz, err := strconv.ParseInt(strings.TrimSpace(freqStr), 10, 64); err == nil && freqHz > 0 {
+		return Reading{Value: float64(freqHz) / 1e9, Source: SourceSysctl, Confidence: ConfidenceExact}, nil
+	}
+	return Reading{}, newWarning(CodeCPUSpeedUndetected, SeverityError, ComponentCPUSpeed)
+}
+
+// detectAppleSiliconGPU uses the chip name as the GPU on Apple Silicon.
+func detectAppleSiliconGPU(ctx context.Context) (Reading, error) {
+	appleSilicon, err := isAppleSilicon(ctx)
+	if err != nil {
+		return Reading{}, err
+	}
+	if !appleSilicon {
+		return Reading{}, newWarning(CodeProbeNotApplicable, SeverityInfo, ComponentGPU).with("detector", "apple-silicon-gpu")
+	}
+	cpuBrand, _ := execCmd(ctx, "sysctl", "-n", "machdep.cpu.brand_string")
+	re := regexp.MustCompile(`Apple M\d+\s*(Pro|Max|Ultra)?`)
+	if match := re.FindString(cleanCPUName(strings.TrimSpace(cpuBrand))); match != "" {
+		return Reading{Value: normalizeGPUName(strings.TrimSpace(match), ""), Source: SourceTable, Confidence: ConfidenceHigh}, nil
+	}
+	return Reading{Value: "Apple Silicon GPU", Source: SourceFallback, Confidence: ConfidenceEstimated}, nil
+}
+
+// detectSystemProfilerGPU reads the discrete/integrated GPU on Intel Macs.
+func detectSystemProfilerGPU(ctx context.Context) (Reading, error) {
+	gpuInfo, err := execCmd(ctx, "system_profiler", "SPDisplaysDataType")
+	if err != nil {
+		return Reading{}, newWarning(CodeGPUUndetected, SeverityError, ComponentGPU).withCause(err)
+	}
+	for _, line := range strings.Split(gpuInfo, "\n") {
+		if strings.Contains(line, "Chipset Model") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				return Reading{Value: normalizeGPUName(strings.TrimSpace(parts[1]), ""), Source: SourceSystemProfiler, Confidence: ConfidenceExact}, nil
 			}
 		}
 	}
+	return Reading{}, newWarning(CodeGPUUndetected, SeverityError, ComponentGPU)
+}
 
-	// RAM
-	memBytes, err := execCmd("sysctl", "-n", "hw.memsize")
+func detectMemSize(ctx context.Context) (Reading, error) {
+	memBytes, err := execCmd(ctx, "sysctl", "-n", "hw.memsize")
 	if err != nil {
-		errors = append(errors, "Could not detect RAM size")
+		return Reading{}, newWarning(CodeRAMUndetected, SeverityError, ComponentRAM).withCause(err)
 	}
 	memBytesInt, _ := strconv.ParseInt(strings.TrimSpace(memBytes), 10, 64)
-	specs.RAMGB = int(memBytesInt / 1073741824)
+	if gb := int(memBytesInt / 1073741824); gb > 0 {
+		return Reading{Value: gb, Source: SourceSysctl, Confidence: ConfidenceExact}, nil
+	}
+	return Reading{}, newWarning(CodeRAMUndetected, SeverityError, ComponentRAM)
+}
 
-	// Storage (free space on root)
-	dfOutput, err := execCmd("df", "-g", "/")
+// detectDfStorage reports free space on the root filesystem.
+func detectDfStorage(ctx context.Context) (Reading, error) {
+	dfOutput, err := execCmd(ctx, "df", "-g", "/")
 	if err != nil {
-		errors = append(errors, "Could not detect storage free space")
-	} else {
-		lines := strings.Split(dfOutput, "\n")
-		if len(lines) >= 2 {
-			fields := strings.Fields(lines[1])
-			if len(fields) >= 4 {
-				specs.StorageGB, _ = strconv.Atoi(fields[3])
+		return Reading{}, newWarning(CodeStorageUndetected, SeverityError, ComponentStorage).withCause(err)
+	}
+	lines := strings.Split(dfOutput, "\n")
+	if len(lines) >= 2 {
+		fields := strings.Fields(lines[1])
+		if len(fields) >= 4 {
+			if free, err := strconv.Atoi(fields[3]); err == nil {
+				return Reading{Value: free, Source: SourceCommand, Confidence: ConfidenceExact}, nil
 			}
 		}
 	}
-
-	return DetectionResult{Specs: specs, Errors: errors}
+	return Reading{}, newWarning(CodeStorageUnparsed, SeverityError, ComponentStorage)
 }
 
-func execCmd(name string, args ...string) (string, error) {
-	out, err := exec.Command(name, args...).Output()
+func execCmd(ctx context.Context, name string, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, name, args...).Output()
 	return string(out), err
 }
 
 // getAppleSiliconSpeed returns known max performance core speeds for Apple Silicon chips.
 // For unknown future generations, it extrapolates from the M3→M4 trend (~0.3 GHz/gen).
-func getAppleSiliconSpeed(cpuName string) (float64, string) {
+func getAppleSiliconSpeed(cpuName string) (float64, *Warning) {
 	knownSpeeds := map[int]float64{
 		1: 3.2,
 		2: 3.5,
@@ -136,19 +189,24 @@ func getAppleSiliconSpeed(cpuName string) (float64, string) {
 	re := regexp.MustCompile(`M(\d+)`)
 	match := re.FindStringSubmatch(cpuName)
 	if len(match) < 2 {
-		return 3.0, "Could not determine Apple Silicon generation, using 3.0 GHz fallback"
+		w := newWarning(CodeCPUSpeedFallback, SeverityWarning, ComponentCPUSpeed)
+		return 3.0, &w
 	}
 
 	gen, err := strconv.Atoi(match[1])
 	if err != nil || gen < 1 {
-		return 3.0, "Could not parse Apple Silicon generation number"
+		w := newWarning(CodeCPUSpeedFallback, SeverityWarning, ComponentCPUSpeed).withCause(err)
+		return 3.0, &w
 	}
 
 	if speed, ok := knownSpeeds[gen]; ok {
-		return speed, ""
+		return speed, nil
 	}
 
 	// Extrapolate for unknown future generations
 	extrapolated := 4.4 + float64(gen-4)*0.3
-	return extrapolated, fmt.Sprintf("CPU speed for M%d is estimated (%.1f GHz)", gen, extrapolated)
+	w := newWarning(CodeCPUSpeedEstimated, SeverityWarning, ComponentCPUSpeed).
+		with("chip", fmt.Sprintf("M%d", gen)).
+		with("ghz", fmt.Sprintf("%.1f", extrapolated))
+	return extrapolated, &w
 }
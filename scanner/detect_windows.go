@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"os/exec"
 	"strings"
@@ -12,18 +13,40 @@ import (
 // CREATE_NO_WINDOW prevents console window from appearing
 const CREATE_NO_WINDOW = 0x08000000
 
-func detectSpecs() DetectionResult {
-	specs := Specs{}
-	var errors []string
+// wmiInfo is the parsed output of the single PowerShell hardware query.
+type wmiInfo struct {
+	OS      string   `json:"os"`
+	CPU     string   `json:"cpu"`
+	Cores   int      `json:"cores"`
+	Speed   int      `json:"speed"` // MHz
+	GPU     string   `json:"gpu"`
+	RAM     int      `json:"ram"`
+	Storage int      `json:"storage"`
+	GPUs    []wmiGPU `json:"gpus"`
+	Chassis int      `json:"chassis"` // SMBIOS chassis type
+}
+
+// wmiGPU is one Win32_VideoController.
+type wmiGPU struct {
+	Name string `json:"name"`
+	PNP  string `json:"pnp"`
+}
 
-	// Run all queries in a single PowerShell call to avoid multiple window flashes
-	script := `
+// queryWMI runs all queries in a single PowerShell call to avoid multiple
+// window flashes. The result is shared by the Windows detectors of one scan
+// only, so a serve rescan queries again.
+func queryWMI(ctx context.Context) (wmiInfo, error) {
+	v, err := runShared(ctx, "wmi", func() (any, error) {
+		script := `
 $os = (Get-CimInstance Win32_OperatingSystem).Caption -replace '^Microsoft\s+', ''
 $cpuInfo = Get-CimInstance Win32_Processor | Select-Object -First 1
 $cpu = $cpuInfo.Name
 $cores = $cpuInfo.NumberOfCores
 $speed = $cpuInfo.MaxClockSpeed
-$gpu = (Get-CimInstance Win32_VideoController | Where-Object { $_.Name -notmatch 'Microsoft Basic Display' -and $_.Name -notmatch 'Microsoft Remote' } | Select-Object -First 1).Name
+$adapters = @(Get-CimInstance Win32_VideoController | Where-Object { $_.Name -notmatch 'Microsoft Basic Display' -and $_.Name -notmatch 'Microsoft Remote' })
+$gpu = ($adapters | Select-Object -First 1).Name
+$gpus = @($adapters | ForEach-Object { @{ name = $_.Name; pnp = $_.PNPDeviceID } })
+$chassis = @((Get-CimInstance Win32_SystemEnclosure | Select-Object -First 1).ChassisTypes)[0]
 $ram = [math]::Round((Get-CimInstance Win32_ComputerSystem).TotalPhysicalMemory / 1GB)
 $storage = [math]::Round((Get-CimInstance Win32_LogicalDisk -Filter "DriveType=3" | Measure-Object -Property FreeSpace -Sum).Sum / 1GB)
 
@@ -33,69 +56,134 @@ $storage = [math]::Round((Get-CimInstance Win32_LogicalDisk -Filter "DriveType=3
     cores = $cores
     speed = $speed
     gpu = $gpu
+    gpus = $gpus
     ram = $ram
     storage = $storage
-} | ConvertTo-Json
+    chassis = $chassis
+} | ConvertTo-Json -Depth 4
 `
+		var w wmiInfo
+		out := powershellHidden(ctx, script)
+		if err := json.Unmarshal([]byte(out), &w); err != nil {
+			return w, newWarning(CodeWMIQueryFailed, SeverityError, "").withCause(err)
+		}
+		return w, nil
+	})
+	w, _ := v.(wmiInfo)
+	return w, err
+}
 
-	out := powershellHidden(script)
-
-	// Parse JSON response
-	var result struct {
-		OS      string `json:"os"`
-		CPU     string `json:"cpu"`
-		Cores   int    `json:"cores"`
-		Speed   int    `json:"speed"` // MHz
-		GPU     string `json:"gpu"`
-		RAM     int    `json:"ram"`
-		Storage int    `json:"storage"`
+func platformDetectors() []Detector {
+	return []Detector{
+		newDetector("wmi-os", ComponentOS, func(ctx context.Context) (Reading, error) {
+			return wmiString(ctx, func(w wmiInfo) string { return w.OS }, newWarning(CodeOSUndetected, SeverityError, ComponentOS))
+		}),
+		newDetector("wmi-cpu", ComponentCPU, func(ctx context.Context) (Reading, error) {
+			return wmiString(ctx, func(w wmiInfo) string { return cleanCPUName(w.CPU) }, newWarning(CodeCPUNameUndetected, SeverityError, ComponentCPU))
+		}),
+		newDetector("wmi-cores", ComponentCPUCores, func(ctx context.Context) (Reading, error) {
+			return wmiInt(ctx, func(w wmiInfo) int { return w.Cores }, newWarning(CodeCPUCoresUndetected, SeverityError, ComponentCPUCores))
+		}),
+		newDetector("wmi-speed", ComponentCPUSpeed, func(ctx context.Context) (Reading, error) {
+			w, err := queryWMI(ctx)
+			if err != nil {
+				return Reading{}, err
+			}
+			if w.Speed == 0 {
+				return Reading{}, newWarning(CodeCPUSpeedUndetected, SeverityError, ComponentCPUSpeed)
+			}
+			return Reading{Value: float64(w.Speed) / 1000.0, Source: SourceWMI, Confidence: ConfidenceExact}, nil // MHz to GHz
+		}),
+		newDetector("wmi-gpu", ComponentGPU, detectWMIGPUs),
+		newDetector("wmi-ram", ComponentRAM, func(ctx context.Context) (Reading, error) {
+			reading, err := wmiInt(ctx, func(w wmiInfo) int { return w.RAM }, newWarning(CodeRAMUndetected, SeverityError, ComponentRAM))
+			reading.Confidence = ConfidenceHigh // rounded from TotalPhysicalMemory
+			return reading, err
+		}),
+		newDetector("wmi-storage", ComponentStorage, func(ctx context.Context) (Reading, error) {
+			return wmiInt(ctx, func(w wmiInfo) int { return w.Storage }, newWarning(CodeStorageUndetected, SeverityError, ComponentStorage))
+		}),
 	}
+}
 
-	if err := json.Unmarshal([]byte(out), &result); err != nil {
-		errors = append(errors, "Failed to parse hardware information from PowerShell: "+err.Error())
-		return DetectionResult{Specs: specs, Errors: errors}
-	}
+// pciVendors maps PCI vendor IDs to the names gpuVendor uses.
+var pciVendors = map[string]string{"10DE": "NVIDIA", "1002": "AMD", "8086": "Intel"}
 
-	specs.OS = strings.TrimSpace(result.OS)
-	if specs.OS == "" {
-		errors = append(errors, "Could not detect OS version")
+// detectWMIGPUs lists every video controller except Windows' own display
+// drivers.
+func detectWMIGPUs(ctx context.Context) (Reading, error) {
+	w, err := queryWMI(ctx)
+	if err != nil {
+		return Reading{}, err
 	}
-
-	specs.CPU = cleanCPUName(strings.TrimSpace(result.CPU))
-	if specs.CPU == "" {
-		errors = append(errors, "Could not detect CPU name")
+	var gpus []GPU
+	for _, a := range w.GPUs {
+		device := strings.TrimSpace(a.Name)
+		if device == "" {
+			continue
+		}
+		gpu := GPU{Vendor: gpuVendor(device), Device: device}
+		gpu.PCIID, gpu.Subsystem = pnpPCIIDs(a.PNP)
+		vendorID, _, _ := strings.Cut(gpu.PCIID, ":")
+		if v, known := pciVendors[strings.ToUpper(vendorID)]; known {
+			gpu.Vendor = v
+		}
+		gpu.Name = normalizeGPUName(device, gpu.PCIID)
+		gpu.Discrete = isDiscreteGPU(gpu.Vendor, device, gpu.Name)
+		gpus = append(gpus, gpu)
 	}
-
-	specs.CPUCores = result.Cores
-	if specs.CPUCores == 0 {
-		errors = append(errors, "Could not detect CPU core count")
+	if len(gpus) > 0 {
+		markMobileGPUs(gpus, w.Chassis)
+		return Reading{Value: gpus, Source: SourceWMI, Confidence: ConfidenceExact}, nil
 	}
+	return wmiString(ctx, func(w wmiInfo) string { return normalizeGPUName(w.GPU, "") }, newWarning(CodeGPUUndetected, SeverityError, ComponentGPU))
+}
 
-	specs.CPUSpeedGHz = float64(result.Speed) / 1000.0 // MHz to GHz
-	if specs.CPUSpeedGHz == 0 {
-		errors = append(errors, "Could not detect CPU speed")
+// pnpPCIIDs reads the PCI IDs from a PNPDeviceID such as
+// PCI\VEN_10DE&DEV_2520&SUBSYS_16C31043&REV_A1. SUBSYS holds the subsystem
+// device before its vendor.
+func pnpPCIIDs(pnp string) (id, subsystem string) {
+	fields := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(pnp), `PCI\`), "&") {
+		if key, value, ok := strings.Cut(part, "_"); ok {
+			fields[key] = strings.ToLower(value)
+		}
 	}
-
-	specs.GPU = strings.TrimSpace(result.GPU)
-	if specs.GPU == "" {
-		errors = append(errors, "Could not detect GPU name")
+	if len(fields["VEN"]) == 4 && len(fields["DEV"]) == 4 {
+		id = fields["VEN"] + ":" + fields["DEV"]
 	}
-
-	specs.RAMGB = result.RAM
-	if specs.RAMGB == 0 {
-		errors = append(errors, "Could not detect RAM size")
+	if sub := fields["SUBSYS"]; len(sub) == 8 {
+		subsystem = sub[4:] + ":" + sub[:4]
 	}
+	return id, subsystem
+}
 
-	specs.StorageGB = result.Storage
-	if specs.StorageGB == 0 {
-		errors = append(errors, "Could not detect storage free space")
+func wmiString(ctx context.Context, field func(wmiInfo) string, missing Warning) (Reading, error) {
+	w, err := queryWMI(ctx)
+	if err != nil {
+		return Reading{}, err
 	}
+	value := strings.TrimSpace(field(w))
+	if value == "" {
+		return Reading{}, missing
+	}
+	return Reading{Value: value, Source: SourceWMI, Confidence: ConfidenceExact}, nil
+}
 
-	return DetectionResult{Specs: specs, Errors: errors}
+func wmiInt(ctx context.Context, field func(wmiInfo) int, missing Warning) (Reading, error) {
+	w, err := queryWMI(ctx)
+	if err != nil {
+		return Reading{}, err
+	}
+	value := field(w)
+	if value == 0 {
+		return Reading{}, missing
+	}
+	return Reading{Value: value, Source: SourceWMI, Confidence: ConfidenceExact}, nil
 }
 
-func powershellHidden(script string) string {
-	cmd := exec.Command("powershell", "-NoProfile", "-Command", script)
+func powershellHidden(ctx context.Context, script string) string {
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script)
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		CreationFlags: CREATE_NO_WINDOW,
 	}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Component identifies which Specs field a detector fills in. The values
+// match the JSON field names of Specs.
+type Component string
+
+const (
+	ComponentOS       Component = "os"
+	ComponentCPU      Component = "cpu"
+	ComponentCPUCores Component = "cpuCores"
+	ComponentCPUSpeed Component = "cpuSpeedGHz"
+	ComponentGPU      Component = "gpu"
+	ComponentRAM      Component = "ramGB"
+	ComponentStorage  Component = "storageGB"
+)
+
+// components lists every Specs field in the order results are assembled.
+var components = []Component{
+	ComponentOS,
+	ComponentCPU,
+	ComponentCPUCores,
+	ComponentCPUSpeed,
+	ComponentGPU,
+	ComponentRAM,
+	ComponentStorage,
+}
+
+// Reading is the value a detector found for its component. Value holds a
+// string for os/cpu/gpu, an int for cpuCores/ramGB/storageGB and a float64
+// for cpuSpeedGHz. GPU detectors that enumerate adapters return a []GPU
+// instead, and the primary one fills gpu. Warnings are kept even when the
+// reading is used. An empty Confidence is treated as ConfidenceHigh.
+type Reading struct {
+	Value      any
+	Source     Source
+	Confidence Confidence
+	Warnings   []Warning
+}
+
+// Detector probes a single Specs field. A detector that returns an error
+// hands over to the next detector registered for the same component. Errors
+// should be Warnings so they carry a stable code.
+type Detector interface {
+	Name() string
+	Component() Component
+	Detect(ctx context.Context) (Reading, error)
+}
+
+// detectorFunc adapts a plain function to the Detector interface.
+type detectorFunc struct {
+	name      string
+	component Component
+	fn        func(ctx context.Context) (Reading, error)
+}
+
+func (d detectorFunc) Name() string         { return d.name }
+func (d detectorFunc) Component() Component { return d.component }
+func (d detectorFunc) Detect(ctx context.Context) (Reading, error) {
+	return d.fn(ctx)
+}
+
+func newDetector(name string, component Component, fn func(ctx context.Context) (Reading, error)) Detector {
+	return detectorFunc{name: name, component: component, fn: fn}
+}
+
+// Registry holds the detectors used to build a DetectionResult. Detectors for
+// the same component are tried in registration order until one succeeds, so
+// later registrations act as fallbacks.
+type Registry struct {
+	detectors []Detector
+	disabled  map[string]bool
+}
+
+func NewRegistry(detectors ...Detector) *Registry {
+	r := &Registry{disabled: make(map[string]bool)}
+	for _, d := range detectors {
+		r.Register(d)
+	}
+	return r
+}
+
+// Register appends a detector, making it the last fallback for its component.
+func (r *Registry) Register(d Detector) {
+	r.detectors = append(r.detectors, d)
+}
+
+// Prepend inserts a detector ahead of every other detector, so it is tried
+// first for its component.
+func (r *Registry) Prepend(d Detector) {
+	r.detectors = append([]Detector{d}, r.detectors...)
+}
+
+// Replace swaps the detector with the given name for d, keeping its position.
+// It reports whether a detector was replaced.
+func (r *Registry) Replace(name string, d Detector) bool {
+	for i, existing := range r.detectors {
+		if existing.Name() == name {
+			r.detectors[i] = d
+			return true
+		}
+	}
+	return false
+}
+
+// Disable skips the named detector when running the registry.
+func (r *Registry) Disable(name string) {
+	r.disabled[name] = true
+}
+
+// Clone returns a copy that can be changed without affecting r.
+func (r *Registry) Clone() *Registry {
+	c := NewRegistry(r.detectors...)
+	for name := range r.disabled {
+		c.disabled[name] = true
+	}
+	return c
+}
+
+// Detectors returns the enabled detectors for a component in the order they
+// will be tried.
+func (r *Registry) Detectors(c Component) []Detector {
+	var out []Detector
+	for _, d := range r.detectors {
+		if d.Component() == c && !r.disabled[d.Name()] {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// DetectOptions bounds how long detection may take. A zero duration means no
+// limit.
+type DetectOptions struct {
+	// ProbeTimeout limits a single detector. When it expires the detector is
+	// abandoned and the next fallback for its component is tried.
+	ProbeTimeout time.Duration
+	// Timeout limits the whole scan. Components still running when it expires
+	// are left empty.
+	Timeout time.Duration
+	// GPU picks the primary adapter when several are found; see
+	// selectPrimaryGPU.
+	GPU string
+}
+
+// defaultDetectOptions keeps a hung probe (e.g. lspci on a broken PCI device)
+// from blocking the GUI progress dialogs forever.
+// The limits can be overridden with DINAU_PROBE_TIMEOUT and DINAU_SCAN_TIMEOUT
+// (Go durations such as "5s"), and the GPU preference with DINAU_GPU.
+var defaultDetectOptions = detectOptionsFromEnv(DetectOptions{
+	ProbeTimeout: 10 * time.Second,
+	Timeout:      20 * time.Second,
+})
+
+func detectOptionsFromEnv(opts DetectOptions) DetectOptions {
+	if d, err := time.ParseDuration(os.Getenv("DINAU_PROBE_TIMEOUT")); err == nil {
+		opts.ProbeTimeout = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("DINAU_SCAN_TIMEOUT")); err == nil {
+		opts.Timeout = d
+	}
+	if gpu := os.Getenv("DINAU_GPU"); gpu != "" {
+		opts.GPU = gpu
+	}
+	return opts
+}
+
+// timeoutWarning records a detector that was abandoned because it ran past
+// its own deadline (overall false) or the scan deadline (overall true).
+func timeoutWarning(d Detector, c Component, after time.Duration, overall bool) Warning {
+	if overall {
+		return newWarning(CodeScanTimeout, SeverityError, c).with("detector", d.Name())
+	}
+	return newWarning(CodeProbeTimeout, SeverityWarning, c).
+		with("detector", d.Name()).
+		with("after", after.String())
+}
+
+// asWarning converts a detector error to a Warning. Detectors normally return
+// a Warning already; other errors are wrapped under a generic code.
+func asWarning(err error, d Detector, c Component) Warning {
+	var w Warning
+	if errors.As(err, &w) {
+		return w
+	}
+	return newWarning(CodeProbeFailed, SeverityError, c).with("detector", d.Name()).withCause(err)
+}
+
+// componentResult is what running the detector chain for one component found.
+type componentResult struct {
+	value      any
+	found      bool
+	provenance Provenance
+	warnings   []Warning
+	// failure is the last detector's error, reported if no detector succeeded.
+	failure *Warning
+}
+
+// Run executes the registered detectors and assembles the DetectionResult.
+// Components are detected concurrently; detectors for the same component run
+// in order as fallbacks. When every detector for a component fails, the last
+// error is reported.
+func (r *Registry) Run(ctx context.Context, opts DetectOptions) DetectionResult {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	ctx = context.WithValue(ctx, runSharedKey{}, &runSharedValues{values: make(map[string]*sharedValue)})
+
+	results := make([]componentResult, len(components))
+	var wg sync.WaitGroup
+	for i, c := range components {
+		wg.Add(1)
+		go func(i int, c Component) {
+			defer wg.Done()
+			results[i] = r.runComponent(ctx, c, opts.ProbeTimeout)
+		}(i, c)
+	}
+	wg.Wait()
+
+	specs := Specs{}
+	provenance := make(map[Component]Provenance)
+	var warnings []Warning
+	seen := make(map[string]bool)
+	report := func(w Warning) {
+		// Detectors sharing one query (e.g. WMI) can fail with the same warning
+		key := fmt.Sprint(w.Code, w.Field, w.Params)
+		if !seen[key] {
+			seen[key] = true
+			warnings = append(warnings, w)
+		}
+	}
+
+	var gpus []GPU
+	for i, c := range components {
+		res := results[i]
+		if list, ok := res.value.([]GPU); ok && res.found {
+			var w *Warning
+			if gpus, w = selectPrimaryGPU(list, opts.GPU); w != nil {
+				report(*w)
+			}
+			res.value = gpus
+		}
+		if res.found {
+			setField(&specs, c, res.value)
+			provenance[c] = res.provenance
+		}
+		for _, w := range res.warnings {
+			report(w)
+		}
+		if res.failure != nil {
+			report(*res.failure)
+		}
+	}
+	specs.Warnings = warningCodes(warnings)
+	applyProvenance(&specs, provenance)
+
+	result := DetectionResult{Specs: specs, Warnings: warnings, Provenance: provenance, GPUs: gpus}
+	if len(gpus) > 0 {
+		result.VRAM = primaryGPU(gpus).VRAM
+	}
+	return result
+}
+
+// runComponent tries each detector for c until one succeeds. Timeouts are
+// always reported; other errors only when no detector succeeds.
+func (r *Registry) runComponent(ctx context.Context, c Component, probeTimeout time.Duration) componentResult {
+	var res componentResult
+	for _, d := range r.Detectors(c) {
+		if ctx.Err() != nil {
+			res.warnings = append(res.warnings, timeoutWarning(d, c, 0, true))
+			return res
+		}
+		reading, err, timedOut := runProbe(ctx, d, probeTimeout)
+		if timedOut {
+			res.warnings = append(res.warnings, timeoutWarning(d, c, probeTimeout, ctx.Err() != nil))
+			continue
+		}
+		if err != nil {
+			w := asWarning(err, d, c)
+			// A detector that does not apply leaves an earlier failure standing
+			if w.Code != CodeProbeNotApplicable || res.failure == nil {
+				res.failure = &w
+			}
+			continue
+		}
+		var probe Specs
+		if err := setField(&probe, c, reading.Value); err != nil {
+			w := newWarning(CodeInvalidProbeReading, SeverityError, c).with("detector", d.Name()).withCause(err)
+			res.failure = &w
+			continue
+		}
+		res.value = reading.Value
+		res.found = true
+		res.provenance = Provenance{Detector: d.Name(), Source: reading.Source, Confidence: reading.Confidence}
+		if res.provenance.Source == "" {
+			res.provenance.Source = SourceUnknown
+		}
+		if res.provenance.Confidence == "" {
+			res.provenance.Confidence = ConfidenceHigh
+		}
+		res.warnings = append(res.warnings, reading.Warnings...)
+		res.failure = nil
+		return res
+	}
+	return res
+}
+
+// runProbe runs a single detector under its own deadline. Detectors that do
+// not honour ctx (e.g. a blocked file read) are abandoned when it expires.
+func runProbe(ctx context.Context, d Detector, timeout time.Duration) (Reading, error, bool) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type outcome struct {
+		reading Reading
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		reading, err := d.Detect(ctx)
+		done <- outcome{reading, err}
+	}()
+
+	select {
+	case o := <-done:
+		if ctx.Err() != nil {
+			// Killed commands return an error once the deadline passes
+			return Reading{}, nil, true
+		}
+		return o.reading, o.err, false
+	case <-ctx.Done():
+		return Reading{}, nil, true
+	}
+}
+
+// runSharedKey is the context key for the values shared within one Run.
+type runSharedKey struct{}
+
+type runSharedValues struct {
+	mu     sync.Mutex
+	values map[string]*sharedValue
+}
+
+type sharedValue struct {
+	once  sync.Once
+	value any
+	err   error
+}
+
+// runShared calls fn once per Registry.Run for key and gives every detector
+// of that run its result, so detectors can share one expensive query (e.g.
+// WMI on Windows) without caching it for the whole process. The first
+// caller's deadline applies to fn. Outside Run, fn is called every time.
+func runShared(ctx context.Context, key string, fn func() (any, error)) (any, error) {
+	shared, ok := ctx.Value(runSharedKey{}).(*runSharedValues)
+	if !ok {
+		return fn()
+	}
+	shared.mu.Lock()
+	v, ok := shared.values[key]
+	if !ok {
+		v = &sharedValue{}
+		shared.values[key] = v
+	}
+	shared.mu.Unlock()
+	v.once.Do(func() { v.value, v.err = fn() })
+	return v.value, v.err
+}
+
+// setField stores a detector value into the matching Specs field.
+func setField(specs *Specs, c Component, value any) error {
+	var ok bool
+	switch c {
+	case ComponentOS:
+		specs.OS, ok = value.(string)
+	case ComponentCPU:
+		specs.CPU, ok = value.(string)
+	case ComponentCPUCores:
+		specs.CPUCores, ok = value.(int)
+	case ComponentCPUSpeed:
+		specs.CPUSpeedGHz, ok = value.(float64)
+	case ComponentGPU:
+		if gpus, isList := value.([]GPU); isList {
+			if len(gpus) == 0 {
+				return fmt.Errorf("empty GPU list")
+			}
+			specs.GPU, ok = primaryGPU(gpus).Name, true
+			break
+		}
+		specs.GPU, ok = value.(string)
+	case ComponentRAM:
+		specs.RAMGB, ok = value.(int)
+	case ComponentStorage:
+		specs.StorageGB, ok = value.(int)
+	default:
+		return fmt.Errorf("unknown component %q", c)
+	}
+	if !ok {
+		return fmt.Errorf("unexpected %T value for %s", value, c)
+	}
+	return nil
+}
+
+// defaultRegistry holds the platform detectors. Extra probes can be added
+// from an init function in another file of this package.
+var defaultRegistry = NewRegistry(platformDetectors()...)
+
+func detectSpecs() DetectionResult {
+	result, _ := detectAndRecord()
+	return result
+}
+
+// detectAndRecord scans this machine, saves the result to the history and
+// returns the previous saved scan.
+func detectAndRecord() (DetectionResult, *historyEntry) {
+	result := defaultRegistry.Run(context.Background(), defaultDetectOptions)
+	result.live = true
+	return result, recordScan(result)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRegistryCloneDisable(t *testing.T) {
+	detect := func(context.Context) (Reading, error) { return Reading{Value: "x"}, nil }
+	r := NewRegistry(newDetector("a", ComponentOS, detect), newDetector("b", ComponentOS, detect))
+	c := r.Clone()
+	c.Disable("a")
+	if got := len(r.Detectors(ComponentOS)); got != 2 {
+		t.Errorf("original registry has %d OS detectors after disabling on the clone, want 2", got)
+	}
+	if got := c.Detectors(ComponentOS); len(got) != 1 || got[0].Name() != "b" {
+		t.Errorf("clone detectors = %v, want only b", got)
+	}
+}
+
+func TestRunSharedPerRun(t *testing.T) {
+	var calls atomic.Int32
+	query := func(ctx context.Context) (Reading, error) {
+		v, err := runShared(ctx, "query", func() (any, error) { return int(calls.Add(1)), nil })
+		return Reading{Value: v}, err
+	}
+	r := NewRegistry(newDetector("cores", ComponentCPUCores, query), newDetector("ram", ComponentRAM, query))
+	for run := int32(1); run <= 2; run++ {
+		result := r.Run(context.Background(), DetectOptions{})
+		if result.Specs.CPUCores != int(run) || result.Specs.RAMGB != int(run) {
+			t.Errorf("run %d: cores %d, ram %d; want both from query %d", run, result.Specs.CPUCores, result.Specs.RAMGB, run)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("query ran %d times over two runs, want 2", got)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// doctorTool is an external program the scanner shells out to. Any one of
+// names is enough.
+type doctorTool struct {
+	names    []string
+	purpose  string
+	required bool
+}
+
+// doctorTools lists the helper programs used on each platform.
+var doctorTools = map[string][]doctorTool{
+	"linux": {
+		{[]string{"lspci"}, "GPU names missing from the built-in PCI ID table, and GPU detection when /sys/bus/pci is not available", false},
+		{[]string{"df"}, "free storage detection", true},
+		{[]string{"nproc"}, "CPU core fallback", false},
+		{[]string{"zenity", "kdialog"}, "GUI dialogs", false},
+		{[]string{"wl-copy", "xclip", "xsel"}, "copying the code to the clipboard", false},
+		{[]string{"xdg-open"}, "opening the browser", false},
+	},
+	"darwin": {
+		{[]string{"sw_vers"}, "OS detection", true},
+		{[]string{"sysctl"}, "CPU and RAM detection", true},
+		{[]string{"system_profiler"}, "GPU detection", true},
+		{[]string{"df"}, "free storage detection", true},
+		{[]string{"open"}, "opening the browser", false},
+	},
+	"windows": {
+		{[]string{"powershell"}, "WMI hardware queries", true},
+		{[]string{"rundll32"}, "opening the browser", false},
+	},
+}
+
+// doctorReport prints check results and remembers whether a required one
+// failed.
+type doctorReport struct {
+	failed bool
+}
+
+func (r *doctorReport) ok(format string, a ...any) {
+	fmt.Printf("[OK] "+format+"\n", a...)
+}
+
+// warn reports a problem that only degrades the scan.
+func (r *doctorReport) warn(format string, a ...any) {
+	fmt.Printf("[--] "+format+"\n", a...)
+}
+
+// fail reports a problem that breaks detection or the website hand-off.
+func (r *doctorReport) fail(format string, a ...any) {
+	r.failed = true
+	fmt.Printf("[!!] "+format+"\n", a...)
+}
+
+// runDoctor checks the environment the scanner depends on and exits with
+// exitFailure when something required is missing.
+func runDoctor(args []string) int {
+	fs := newFlagSet("doctor")
+	offline := fs.Bool("offline", false, "skip the website reachability check")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	var r doctorReport
+	fmt.Printf("DoINeedAnUpgrade scanner %s on %s/%s\n\n", version, runtime.GOOS, runtime.GOARCH)
+
+	for _, tool := range doctorTools[runtime.GOOS] {
+		checkTool(&r, tool)
+	}
+	if runtime.GOOS == "linux" {
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			r.warn("no graphical session: double-click launches will fall back to the terminal")
+		} else {
+			r.ok("graphical session available")
+		}
+	}
+
+	checkConfig(&r)
+	if *offline {
+		r.warn("website reachability not checked (--offline)")
+	} else {
+		checkWebsite(&r, baseURL)
+	}
+
+	if r.failed {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func checkTool(r *doctorReport, tool doctorTool) {
+	for _, name := range tool.names {
+		if path, err := exec.LookPath(name); err == nil {
+			r.ok("%s found (%s), used for %s", name, path, tool.purpose)
+			return
+		}
+	}
+	names := strings.Join(tool.names, " or ")
+	if tool.required {
+		r.fail("%s not found, needed for %s", names, tool.purpose)
+	} else {
+		r.warn("%s not found, used for %s", names, tool.purpose)
+	}
+}
+
+func checkConfig(r *doctorReport) {
+	path := configPath()
+	if path == "" {
+		r.warn("no user config directory")
+		return
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		r.ok("no config file at %s (using defaults)", path)
+		return
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		r.fail("config file: %v", err)
+		return
+	}
+	if cfg.BaseURL != "" {
+		if _, err := validateBaseURL(cfg.BaseURL); err != nil {
+			r.fail("config file %s: %v", path, err)
+			return
+		}
+	}
+	if _, err := resolvePrivacy("", false, cfg); err != nil {
+		r.fail("%v", err)
+		return
+	}
+	r.ok("config file %s", path)
+}
+
+func checkWebsite(r *doctorReport, site string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, site, nil)
+	if err != nil {
+		r.fail("website %s: %v", site, err)
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		r.fail("website %s unreachable: %v", site, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		r.fail("website %s returned %s", site, resp.Status)
+		return
+	}
+	r.ok("website %s reachable (%s)", site, resp.Status)
+}
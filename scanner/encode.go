@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// specsFlagNames maps encode's field flags to Specs JSON names.
+var specsFlagNames = []struct{ flag, field string }{
+	{"os", "os"},
+	{"cpu", "cpu"},
+	{"cpu-cores", "cpuCores"},
+	{"cpu-speed", "cpuSpeedGHz"},
+	{"gpu", "gpu"},
+	{"ram", "ramGB"},
+	{"storage", "storageGB"},
+}
+
+// Upper bounds for hand-entered values. They only catch typos such as RAM
+// entered in MB.
+const (
+	maxCPUCores  = 1024
+	maxCPUGHz    = 10
+	maxRAMGB     = 16384
+	maxStorageGB = 1 << 20
+)
+
+// parseSpecsDocument reads Specs from a JSON or YAML document. Warnings
+// lists from `scan --format` output are dropped: hand-written specs carry no
+// detection warnings.
+func parseSpecsDocument(data []byte, yaml bool) (map[string]any, error) {
+	var fields map[string]any
+	if yaml {
+		var err error
+		if fields, err = parseFlatYAML(string(data)); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if fields == nil {
+		return nil, errors.New("specs document is empty")
+	}
+	delete(fields, "warnings")
+	return fields, nil
+}
+
+// parseFlatYAML parses the YAML subset written by `scan --format yaml`:
+// top-level "key: value" lines with scalars or [inline, lists]. Indented
+// lines (the warnings block) and comments are skipped.
+func parseFlatYAML(doc string) (map[string]any, error) {
+	fields := make(map[string]any)
+	for i, line := range strings.Split(doc, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || line[0] == '-' {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid YAML on line %d: want \"key: value\"", i+1)
+		}
+		v, err := yamlScalar(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML on line %d: %w", i+1, err)
+		}
+		if v != nil {
+			fields[strings.TrimSpace(key)] = v
+		}
+	}
+	return fields, nil
+}
+
+func yamlScalar(s string) (any, error) {
+	switch {
+	case s == "" || s == "~" || s == "null":
+		return nil, nil
+	case s == "true" || s == "false":
+		return s == "true", nil
+	case strings.HasPrefix(s, `"`):
+		var str string
+		if err := json.Unmarshal([]byte(s), &str); err != nil {
+			return nil, fmt.Errorf("bad quoted string %s", s)
+		}
+		return str, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("bad quoted string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated list %s", s)
+		}
+		list := []any{}
+		for _, item := range strings.Split(s[1:len(s)-1], ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			v, err := yamlScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n, nil
+	}
+	return s, nil
+}
+
+// specsFromFields validates fields like a decoded payload and normalises the
+// names the same way the detectors do.
+func specsFromFields(fields map[string]any) (Specs, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return Specs{}, err
+	}
+	if err := validatePayloadJSON(data); err != nil {
+		return Specs{}, err
+	}
+	var specs Specs
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return Specs{}, err
+	}
+	specs.OS = strings.TrimSpace(specs.OS)
+	specs.CPU = cleanCPUName(specs.CPU)
+	specs.GPU = strings.TrimSpace(specs.GPU)
+	return specs, validateSpecs(specs)
+}
+
+// validateSpecs applies the website's import rules (os and cpu required)
+// and rejects values outside plausible ranges.
+func validateSpecs(specs Specs) error {
+	switch {
+	case specs.OS == "":
+		return errors.New("os is required")
+	case specs.CPU == "":
+		return errors.New("cpu is required")
+	case specs.CPUCores < 0 || specs.CPUCores > maxCPUCores:
+		return fmt.Errorf("cpuCores %d is out of range (0-%d)", specs.CPUCores, maxCPUCores)
+	case specs.CPUSpeedGHz < 0 || specs.CPUSpeedGHz > maxCPUGHz:
+		return fmt.Errorf("cpuSpeedGHz %g is out of range (0-%d); give the speed in GHz", specs.CPUSpeedGHz, maxCPUGHz)
+	case specs.RAMGB < 0 || specs.RAMGB > maxRAMGB:
+		return fmt.Errorf("ramGB %d is out of range (0-%d); give RAM in GB", specs.RAMGB, maxRAMGB)
+	case specs.StorageGB < 0 || specs.StorageGB > maxStorageGB:
+		return fmt.Errorf("storageGB %d is out of range (0-%d); give storage in GB", specs.StorageGB, maxStorageGB)
+	}
+	return nil
+}
+
+// runEncode turns specs from flags, a JSON/YAML file or stdin into a DINAU
+// code, a ?specs= link or an ?import= link, without detecting anything.
+func runEncode(args []string) int {
+	fs := newFlagSet("encode")
+	values := make(map[string]*string)
+	for _, f := range specsFlagNames {
+		values[f.flag] = fs.String(f.flag, "", "set "+f.field)
+	}
+	output := fs.String("output", "code", "what to print: code, url (?specs= link) or import (upload and print the ?import= link)")
+	asURL := fs.Bool("url", false, "shorthand for --output url")
+	yamlInput := fs.Bool("yaml", false, "read the input as YAML (default: by file extension, or JSON if it starts with '{')")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *asURL {
+		*output = "url"
+	}
+	if *output != "code" && *output != "url" && *output != "import" {
+		fmt.Fprintf(os.Stderr, "invalid --output %q: want code, url or import\n", *output)
+		return exitUsage
+	}
+
+	setFlags := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	fromFlags := false
+	for _, f := range specsFlagNames {
+		fromFlags = fromFlags || setFlags[f.flag]
+	}
+
+	fields := make(map[string]any)
+	// Read a document unless the specs are given entirely as flags
+	if fs.NArg() > 0 || !fromFlags {
+		name := fs.Arg(0)
+		data, err := readInput(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		isYAML := *yamlInput || ext == ".yaml" || ext == ".yml" ||
+			(ext != ".json" && !strings.HasPrefix(strings.TrimSpace(string(data)), "{"))
+		if fields, err = parseSpecsDocument(data, isYAML); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+	}
+	for _, f := range specsFlagNames {
+		if !setFlags[f.flag] {
+			continue
+		}
+		v := *values[f.flag]
+		if f.field == "os" || f.field == "cpu" || f.field == "gpu" {
+			fields[f.field] = v
+			continue
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --%s %q: want a number\n", f.flag, v)
+			return exitUsage
+		}
+		fields[f.field] = n
+	}
+
+	specs, err := specsFromFields(fields)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid specs: %v\n", err)
+		return exitFailure
+	}
+
+	switch *output {
+	case "url":
+		fmt.Println(getURL(DetectionResult{Specs: specs}))
+	case "import":
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		client := newImportClient(baseURL)
+		token, err := client.importSpecs(ctx, Payload{Specs: specs})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not upload specs: %v\n", err)
+			return exitFailure
+		}
+		fmt.Println(client.importURL(token))
+	default:
+		fmt.Println(encodeSpecs(specs))
+	}
+	return exitOK
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// commandRunner runs an external command and returns its stdout. Detectors go
+// through it instead of os/exec so command output can come from fixtures.
+type commandRunner interface {
+	Output(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// execRunner runs commands on the host.
+type execRunner struct{}
+
+func (execRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// probeEnv is the machine a detector inspects: a filesystem rooted at "/" and
+// a way to run commands.
+type probeEnv struct {
+	fs  fs.FS
+	cmd commandRunner
+}
+
+// hostEnv inspects the machine the scanner is running on.
+func hostEnv() probeEnv {
+	return probeEnv{fs: os.DirFS("/"), cmd: execRunner{}}
+}
+
+// readFile reads an absolute path such as "/proc/cpuinfo" from the env root.
+func (e probeEnv) readFile(path string) ([]byte, error) {
+	return fs.ReadFile(e.fs, strings.TrimPrefix(path, "/"))
+}
+
+// readDir lists an absolute directory such as "/sys/bus/pci/devices".
+func (e probeEnv) readDir(path string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(e.fs, strings.TrimPrefix(path, "/"))
+}
+
+func (e probeEnv) output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return e.cmd.Output(ctx, name, args...)
+}
+
+// commandLine is the key used to look up recorded command output.
+func commandLine(name string, args ...string) string {
+	return strings.Join(append([]string{name}, args...), " ")
+}
+
+// recordedOutput is the stored result of one command invocation.
+type recordedOutput struct {
+	Stdout string `json:"stdout"`
+	Error  string `json:"error,omitempty"`
+}
+
+// fixtureRunner answers commands from recorded output. Commands that were
+// not recorded fail as if the tool were not installed.
+type fixtureRunner map[string]recordedOutput
+
+func (r fixtureRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	rec, ok := r[commandLine(name, args...)]
+	if !ok {
+		return nil, fmt.Errorf("exec: %q: executable file not found in $PATH", name)
+	}
+	if rec.Error != "" {
+		return []byte(rec.Stdout), fmt.Errorf("%s", rec.Error)
+	}
+	return []byte(rec.Stdout), nil
+}
+
+// fixtureEnv loads a fixture directory: files under root/ stand in for the
+// filesystem and commands.json holds the output of each command line.
+func fixtureEnv(dir string) (probeEnv, error) {
+	runner := fixtureRunner{}
+	data, err := os.ReadFile(filepath.Join(dir, "commands.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return probeEnv{}, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &runner); err != nil {
+			return probeEnv{}, fmt.Errorf("parse commands.json: %w", err)
+		}
+	}
+	return probeEnv{fs: os.DirFS(filepath.Join(dir, "root")), cmd: runner}, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFixtures replays every fixture in testdata/fixtures through the Linux
+// detectors and compares the Specs with its specs.golden.json and the GPU
+// list and primary VRAM with its gpus.golden.json, both from the directory and
+// from the same files packed as a snapshot archive. -update rewrites
+// gpus.golden.json; specs.golden.json comes from `make update-fixtures`.
+func TestFixtures(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "fixtures", "*", "specs.golden.json"))
+	if err != nil || len(dirs) == 0 {
+		t.Fatalf("no fixtures found: %v", err)
+	}
+	for _, golden := range dirs {
+		dir := filepath.Dir(golden)
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			wantSpecs, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gpusGolden := filepath.Join(dir, "gpus.golden.json")
+			if *updateGolden {
+				specs, gpus := replayFixture(t, dir)
+				if err := os.WriteFile(gpusGolden, []byte(gpus+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if specs != strings.TrimSpace(string(wantSpecs)) {
+					t.Errorf("%s is out of date: run make update-fixtures", golden)
+				}
+				return
+			}
+			wantGPUs, err := os.ReadFile(gpusGolden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			archive := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+			if err := writeTarGz(archive, fixtureEntries(t, dir)); err != nil {
+				t.Fatal(err)
+			}
+			for _, src := range []string{dir, archive} {
+				specs, gpus := replayFixture(t, src)
+				if specs != strings.TrimSpace(string(wantSpecs)) {
+					t.Errorf("replaying %s:\ngot  %s\nwant %s", src, specs, wantSpecs)
+				}
+				if gpus != strings.TrimSpace(string(wantGPUs)) {
+					t.Errorf("replaying %s GPUs:\ngot  %s\nwant %s", src, gpus, wantGPUs)
+				}
+			}
+		})
+	}
+}
+
+// replayFixture detects from a fixture directory or archive and returns the
+// Specs formatted like specs.golden.json and the GPUs and VRAM formatted like
+// gpus.golden.json.
+func replayFixture(t *testing.T, src string) (specs, gpus string) {
+	t.Helper()
+	env, err := snapshotEnv(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := NewRegistry(linuxDetectors(env)...).Run(context.Background(), defaultDetectOptions)
+	specsJSON, err := json.MarshalIndent(result.Specs, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gpusJSON, err := json.MarshalIndent(struct {
+		GPUs []GPU `json:"gpus"`
+		VRAM *VRAM `json:"vram"`
+	}{result.GPUs, result.VRAM}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(specsJSON), string(gpusJSON)
+}
+
+// fixtureEntries reads a fixture directory into archive entries.
+func fixtureEntries(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	entries := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		entries[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatter writes a DetectionResult to w in one output format.
+type formatter func(w io.Writer, result DetectionResult) error
+
+// formatters is the registry behind --format. Every format except text uses
+// the field names of the website's UserSpecs.
+var formatters = map[string]formatter{
+	"text":     formatText,
+	"json":     formatJSON,
+	"yaml":     formatYAML,
+	"toml":     formatTOML,
+	"csv":      formatCSV,
+	"markdown": formatMarkdown,
+}
+
+// formatNames lists the registered formats for flag help and errors.
+func formatNames() string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func lookupFormat(name string) (formatter, error) {
+	if name == "md" {
+		name = "markdown"
+	}
+	if f, ok := formatters[name]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unknown format %q: want one of %s", name, formatNames())
+}
+
+// specsField is one named value of the flat record the formats share.
+type specsField struct {
+	name  string
+	value any // string, int, float64, bool or []string
+}
+
+// specsFields returns the Specs in UserSpecs order. Warnings are reported
+// separately by reportWarnings.
+func specsFields(specs Specs) []specsField {
+	guessed := specs.GuessedFields
+	if guessed == nil {
+		guessed = []string{}
+	}
+	return []specsField{
+		{"os", specs.OS},
+		{"cpu", specs.CPU},
+		{"cpuCores", specs.CPUCores},
+		{"cpuSpeedGHz", specs.CPUSpeedGHz},
+		{"gpu", specs.GPU},
+		{"ramGB", specs.RAMGB},
+		{"storageGB", specs.StorageGB},
+		{"ramApproximate", specs.RAMApproximate},
+		{"guessedFields", guessed},
+	}
+}
+
+// gpuFields returns one adapter's fields for the yaml and toml lists, leaving
+// out what the platform did not report. json marshals GPU itself.
+func gpuFields(g GPU) []specsField {
+	fields := []specsField{{"name", g.Name}}
+	for _, f := range []specsField{{"vendor", g.Vendor}, {"device", g.Device}, {"slot", g.Slot}, {"pciId", g.PCIID}, {"subsystem", g.Subsystem}} {
+		if f.value != "" {
+			fields = append(fields, f)
+		}
+	}
+	fields = append(fields,
+		specsField{"discrete", g.Discrete},
+		specsField{"mobile", g.Mobile},
+		specsField{"bootVga", g.BootVGA},
+		specsField{"primary", g.Primary},
+	)
+	if g.VRAM != nil {
+		fields = append(fields, specsField{"vramMB", g.VRAM.MB})
+	}
+	return fields
+}
+
+func vramFields(v *VRAM) []specsField {
+	return []specsField{
+		{"mb", v.MB},
+		{"shared", v.Shared},
+		{"detector", v.Detector},
+		{"source", string(v.Source)},
+		{"confidence", string(v.Confidence)},
+	}
+}
+
+// adapterNames lists every adapter's name for the one-cell csv and markdown
+// forms.
+func adapterNames(gpus []GPU) []string {
+	names := make([]string, 0, len(gpus))
+	for _, g := range gpus {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// warningRecord is how warnings appear in structured output.
+type warningRecord struct {
+	Code     string    `json:"code"`
+	Severity Severity  `json:"severity,omitempty"`
+	Field    Component `json:"field,omitempty"`
+	Message  string    `json:"message"`
+}
+
+// reportWarnings returns the result's warnings in the user's locale. Results
+// decoded from a bare code only know the warning codes.
+func reportWarnings(result DetectionResult) []warningRecord {
+	warnings := result.Warnings
+	if len(warnings) == 0 {
+		for _, code := range result.Specs.Warnings {
+			warnings = append(warnings, Warning{Code: code})
+		}
+	}
+	locale := userLocale()
+	records := make([]warningRecord, 0, len(warnings))
+	for _, w := range warnings {
+		records = append(records, warningRecord{Code: w.Code, Severity: w.Severity, Field: w.Field, Message: w.Message(locale)})
+	}
+	return records
+}
+
+// quoteString quotes s as a JSON string, which is also a valid YAML
+// double-quoted scalar and TOML basic string once DEL and the C1 controls,
+// which JSON leaves as they are, are escaped too.
+func quoteString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	var out strings.Builder
+	for _, r := range strings.TrimSuffix(buf.String(), "\n") {
+		if r >= 0x7f && r <= 0x9f {
+			fmt.Fprintf(&out, `\u%04x`, r)
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// markdownEscape keeps a value inside one Markdown table cell or list item.
+var markdownEscape = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", " ", "\r", " ", "\n", " ")
+
+// scalar renders a field value in YAML/TOML syntax, which agree for the
+// types specsFields uses.
+func scalar(v any) string {
+	switch v := v.(type) {
+	case string:
+		return quoteString(v)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = quoteString(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	return quoteString(fmt.Sprint(v))
+}
+
+// plain renders a field value for CSV cells and Markdown tables.
+func plain(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ";")
+	}
+	return fmt.Sprint(v)
+}
+
+func formatText(w io.Writer, result DetectionResult) error {
+	printResult(w, result)
+	return nil
+}
+
+func formatJSON(w io.Writer, result DetectionResult) error {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for _, f := range specsFields(result.Specs) {
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "  %q: %s,\n", f.name, value)
+	}
+	if len(result.GPUs) > 0 {
+		gpus, err := json.MarshalIndent(result.GPUs, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "  \"gpus\": %s,\n", gpus)
+	}
+	if result.VRAM != nil {
+		vram, err := json.MarshalIndent(result.VRAM, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "  \"vram\": %s,\n", vram)
+	}
+	warnings, err := json.MarshalIndent(reportWarnings(result), "  ", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&buf, "  \"warnings\": %s\n}\n", warnings)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func formatYAML(w io.Writer, result DetectionResult) error {
+	var buf bytes.Buffer
+	for _, f := range specsFields(result.Specs) {
+		fmt.Fprintf(&buf, "%s: %s\n", f.name, scalar(f.value))
+	}
+	if len(result.GPUs) > 0 {
+		buf.WriteString("gpus:\n")
+	}
+	for _, g := range result.GPUs {
+		for i, f := range gpuFields(g) {
+			indent := "    "
+			if i == 0 {
+				indent = "  - "
+			}
+			fmt.Fprintf(&buf, "%s%s: %s\n", indent, f.name, scalar(f.value))
+		}
+	}
+	if result.VRAM != nil {
+		buf.WriteString("vram:\n")
+		for _, f := range vramFields(result.VRAM) {
+			fmt.Fprintf(&buf, "  %s: %s\n", f.name, scalar(f.value))
+		}
+	}
+	warnings := reportWarnings(result)
+	if len(warnings) == 0 {
+		buf.WriteString("warnings: []\n")
+	} else {
+		buf.WriteString("warnings:\n")
+	}
+	for _, wr := range warnings {
+		fmt.Fprintf(&buf, "  - code: %s\n", quoteString(wr.Code))
+		if wr.Severity != "" {
+			fmt.Fprintf(&buf, "    severity: %s\n", quoteString(string(wr.Severity)))
+		}
+		if wr.Field != "" {
+			fmt.Fprintf(&buf, "    field: %s\n", quoteString(string(wr.Field)))
+		}
+		fmt.Fprintf(&buf, "    message: %s\n", quoteString(wr.Message))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func formatTOML(w io.Writer, result DetectionResult) error {
+	var buf bytes.Buffer
+	for _, f := range specsFields(result.Specs) {
+		fmt.Fprintf(&buf, "%s = %s\n", f.name, scalar(f.value))
+	}
+	for _, g := range result.GPUs {
+		buf.WriteString("\n[[gpus]]\n")
+		for _, f := range gpuFields(g) {
+			fmt.Fprintf(&buf, "%s = %s\n", f.name, scalar(f.value))
+		}
+	}
+	if result.VRAM != nil {
+		buf.WriteString("\n[vram]\n")
+		for _, f := range vramFields(result.VRAM) {
+			fmt.Fprintf(&buf, "%s = %s\n", f.name, scalar(f.value))
+		}
+	}
+	for _, wr := range reportWarnings(result) {
+		buf.WriteString("\n[[warnings]]\n")
+		fmt.Fprintf(&buf, "code = %s\n", quoteString(wr.Code))
+		if wr.Severity != "" {
+			fmt.Fprintf(&buf, "severity = %s\n", quoteString(string(wr.Severity)))
+		}
+		if wr.Field != "" {
+			fmt.Fprintf(&buf, "field = %s\n", quoteString(string(wr.Field)))
+		}
+		fmt.Fprintf(&buf, "message = %s\n", quoteString(wr.Message))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// formatCSV writes a header and one row, so several machines' output can be
+// concatenated with the header lines dropped. List values are joined with ";".
+// GPUs are reduced to their names and VRAM to the primary GPU's size; the
+// other formats carry the full records.
+func formatCSV(w io.Writer, result DetectionResult) error {
+	fields := specsFields(result.Specs)
+	header := make([]string, 0, len(fields)+1)
+	row := make([]string, 0, len(fields)+1)
+	for _, f := range fields {
+		header = append(header, f.name)
+		row = append(row, plain(f.value))
+	}
+	vramMB := ""
+	if result.VRAM != nil {
+		vramMB = strconv.Itoa(result.VRAM.MB)
+	}
+	var codes []string
+	for _, wr := range reportWarnings(result) {
+		codes = append(codes, wr.Code)
+	}
+	header = append(header, "gpus", "vramMB", "warnings")
+	row = append(row, plain(adapterNames(result.GPUs)), vramMB, strings.Join(codes, ";"))
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.Write(row)
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatMarkdown(w io.Writer, result DetectionResult) error {
+	var buf bytes.Buffer
+	buf.WriteString("| Field | Value |\n|---|---|\n")
+	for _, f := range specsFields(result.Specs) {
+		fmt.Fprintf(&buf, "| %s | %s |\n", f.name, markdownEscape.Replace(plain(f.value)))
+	}
+	if len(result.GPUs) > 0 {
+		fmt.Fprintf(&buf, "| gpus | %s |\n", markdownEscape.Replace(strings.Join(adapterNames(result.GPUs), "; ")))
+	}
+	if result.VRAM != nil {
+		fmt.Fprintf(&buf, "| vram | %s |\n", markdownEscape.Replace(result.VRAM.String()))
+	}
+	if warnings := reportWarnings(result); len(warnings) > 0 {
+		buf.WriteString("\n**Warnings**\n\n")
+		for _, wr := range warnings {
+			fmt.Fprintf(&buf, "- `%s` %s\n", wr.Code, markdownEscape.Replace(wr.Message))
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
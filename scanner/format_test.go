@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/format and the fixtures' gpus.golden.json")
+
+// awkwardResult has values every format has to quote or escape.
+func awkwardResult() DetectionResult {
+	vram := &VRAM{MB: 16380, Provenance: Provenance{Detector: "nvidia-smi", Source: SourceCommand, Confidence: ConfidenceExact}}
+	return DetectionResult{
+		Specs: Specs{
+			OS:            "Linux: \"Edge\" | build 7, rc\nsecond line\\tail",
+			CPU:           "AMD Ryzen 7 5800X # 8-Core, #1",
+			CPUCores:      8,
+			CPUSpeedGHz:   4.85,
+			GPU:           "NVIDIA GeForce RTX 4060 Ti",
+			RAMGB:         32,
+			StorageGB:     412,
+			Warnings:      []string{CodeGPUUnnamedDevice},
+			GuessedFields: []string{"CPU", "GPU: \"x\", y"},
+		},
+		Warnings: []Warning{
+			newWarning(CodeGPUUnnamedDevice, SeverityWarning, ComponentGPU).with("device", "10de:2805 \"a|b\"\r\n[c]"),
+		},
+		GPUs: []GPU{
+			{Name: "NVIDIA GeForce RTX 4060 Ti", Vendor: "NVIDIA", Device: "AD106 [GeForce RTX 4060 Ti 16GB]", Slot: "0000:01:00.0", PCIID: "10de:2805", Subsystem: "1462:5174", Discrete: true, Primary: true, VRAM: vram},
+			{Name: "Intel UHD Graphics 770, rev\u007f\u0085", Vendor: "Intel", BootVGA: true},
+		},
+		VRAM: vram,
+	}
+}
+
+func TestFormatGolden(t *testing.T) {
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+	result := awkwardResult()
+	for _, name := range []string{"json", "yaml", "toml", "csv", "markdown"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := lookupFormat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := f(&buf, result); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "format", "awkward."+name)
+			if *updateGolden {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("%s output differs from %s:\n%s", name, golden, got)
+			}
+		})
+	}
+}
+
+func TestFormatJSONParses(t *testing.T) {
+	var buf bytes.Buffer
+	if err := formatJSON(&buf, awkwardResult()); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		OS   string `json:"os"`
+		GPUs []GPU  `json:"gpus"`
+		VRAM *VRAM  `json:"vram"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v\n%s", err, buf.String())
+	}
+	want := awkwardResult()
+	if got.OS != want.Specs.OS || len(got.GPUs) != 2 || got.GPUs[1].Name != want.GPUs[1].Name || got.VRAM == nil || got.VRAM.MB != want.VRAM.MB {
+		t.Errorf("decoded %+v, want the result's os, gpus and vram", got)
+	}
+}
+
+func TestFormatCSVParses(t *testing.T) {
+	var buf bytes.Buffer
+	if err := formatCSV(&buf, awkwardResult()); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[0]) != len(records[1]) {
+		t.Fatalf("records %q, want a header and one row of the same width", records)
+	}
+	row := make(map[string]string)
+	for i, name := range records[0] {
+		row[name] = records[1][i]
+	}
+	want := awkwardResult()
+	if row["os"] != want.Specs.OS || row["gpus"] != strings.Join(adapterNames(want.GPUs), ";") || row["vramMB"] != "16380" {
+		t.Errorf("row %q does not hold the result's os, gpus and vramMB", row)
+	}
+}
+
+// TestFormatMarkdownCells checks every table row keeps two cells, however
+// many pipes and line breaks the values contain.
+func TestFormatMarkdownCells(t *testing.T) {
+	var buf bytes.Buffer
+	if err := formatMarkdown(&buf, awkwardResult()); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+		line = strings.ReplaceAll(line, `\\`, "")
+		if unescaped := strings.Count(line, "|") - strings.Count(line, `\|`); unescaped != 3 {
+			t.Errorf("row %q has %d cell separators, want 3", line, unescaped)
+		}
+	}
+}
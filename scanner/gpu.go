@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GPU is one display adapter found during detection. Specs.GPU is the Name of
+// the adapter marked Primary.
+type GPU struct {
+	Name   string `json:"name"`
+	Vendor string `json:"vendor,omitempty"`
+	// Device is the description reported by the system, before cleaning.
+	Device string `json:"device,omitempty"`
+	// Slot is the PCI address, e.g. "0000:01:00.0", where known.
+	Slot string `json:"slot,omitempty"`
+	// PCIID and Subsystem are "vendor:device" hex IDs, e.g. "10de:2560",
+	// where the platform reports them.
+	PCIID     string `json:"pciId,omitempty"`
+	Subsystem string `json:"subsystem,omitempty"`
+	Discrete  bool   `json:"discrete"`
+	// Mobile marks a laptop variant of a discrete GPU, named with the
+	// website's "Laptop" suffix.
+	Mobile bool `json:"mobile,omitempty"`
+	// BootVGA marks the adapter the firmware initialised the display on.
+	BootVGA bool  `json:"bootVga,omitempty"`
+	Primary bool  `json:"primary,omitempty"`
+	VRAM    *VRAM `json:"vram,omitempty"`
+}
+
+// VRAM is an adapter's video memory and how it was detected. Detector names
+// the driver interface read, e.g. "amdgpu" or "nvidia-smi".
+type VRAM struct {
+	MB int `json:"mb"`
+	// Shared marks memory carved out of system RAM by an integrated GPU.
+	Shared bool `json:"shared,omitempty"`
+	Provenance
+}
+
+// String formats the amount as game requirements do, e.g. "12 GB".
+func (v VRAM) String() string {
+	size := fmt.Sprintf("%d MB", v.MB)
+	if v.MB >= 1024 {
+		size = strconv.FormatFloat(math.Round(float64(v.MB)/1024*10)/10, 'f', -1, 64) + " GB"
+	}
+	if v.Shared {
+		size += " shared"
+	}
+	return size
+}
+
+// Payload sections listing every detected GPU and the primary one's VRAM.
+const (
+	extGPUs = "gpus"
+	extVRAM = "vram"
+)
+
+// GPU preferences accepted by --gpu, DINAU_GPU and "gpu" in the config file.
+// Any other value selects the adapter whose PCI slot equals it or whose name
+// contains it, e.g. "01:00.0" or "nvidia".
+const (
+	gpuPreferAuto       = "auto"       // discrete, then boot_vga, then the first listed
+	gpuPreferDiscrete   = "discrete"   // same as auto
+	gpuPreferIntegrated = "integrated" // integrated, then boot_vga
+	gpuPreferBoot       = "boot"       // the boot_vga adapter
+)
+
+// selectPrimaryGPU marks the adapter that Specs.GPU reports. It returns a
+// warning when a preference names no detected adapter; the default choice
+// is used then.
+func selectPrimaryGPU(gpus []GPU, preference string) ([]GPU, *Warning) {
+	if len(gpus) == 0 {
+		return gpus, nil
+	}
+	out := make([]GPU, len(gpus))
+	copy(out, gpus)
+	for i := range out {
+		out[i].Primary = false
+	}
+
+	// best returns the first adapter for which the checks hold, trying each
+	// check in turn as a tie-breaker.
+	best := func(checks ...func(GPU) bool) int {
+		candidates := make([]int, len(out))
+		for i := range out {
+			candidates[i] = i
+		}
+		for _, check := range checks {
+			var kept []int
+			for _, i := range candidates {
+				if check(out[i]) {
+					kept = append(kept, i)
+				}
+			}
+			if len(kept) > 0 {
+				candidates = kept
+			}
+		}
+		return candidates[0]
+	}
+	discrete := func(g GPU) bool { return g.Discrete }
+	integrated := func(g GPU) bool { return !g.Discrete }
+	boot := func(g GPU) bool { return g.BootVGA }
+
+	var warning *Warning
+	pick := -1
+	switch pref := strings.ToLower(strings.TrimSpace(preference)); pref {
+	case "", gpuPreferAuto, gpuPreferDiscrete:
+	case gpuPreferIntegrated:
+		pick = best(integrated, boot)
+	case gpuPreferBoot:
+		pick = best(boot, discrete)
+	default:
+		for i, g := range out {
+			slot := strings.ToLower(g.Slot)
+			if slot != "" && (slot == pref || strings.TrimPrefix(slot, "0000:") == pref) {
+				pick = i
+				break
+			}
+		}
+		if pick < 0 {
+			for i, g := range out {
+				if strings.Contains(strings.ToLower(g.Name), pref) || strings.Contains(strings.ToLower(g.Vendor), pref) {
+					pick = i
+					break
+				}
+			}
+		}
+		if pick < 0 {
+			w := newWarning(CodeGPUPreferenceUnmatched, SeverityWarning, ComponentGPU).with("preference", preference)
+			warning = &w
+		}
+	}
+	if pick < 0 {
+		pick = best(discrete, boot)
+	}
+	out[pick].Primary = true
+	return out, warning
+}
+
+// primaryGPU returns the adapter marked Primary, or the first one.
+func primaryGPU(gpus []GPU) GPU {
+	for _, g := range gpus {
+		if g.Primary {
+			return g
+		}
+	}
+	return gpus[0]
+}
+
+// gpuVendor names the vendor of a device description such as
+// "NVIDIA Corporation GA106M [GeForce RTX 3060 Mobile / Max-Q]".
+func gpuVendor(device string) string {
+	lower := strings.ToLower(device)
+	switch {
+	case strings.Contains(lower, "nvidia"):
+		return "NVIDIA"
+	case amdVendorRe.MatchString(device):
+		return "AMD"
+	case strings.Contains(lower, "intel"):
+		return "Intel"
+	case strings.Contains(lower, "apple"):
+		return "Apple"
+	}
+	fields := strings.Fields(device)
+	if len(fields) == 0 || fields[0] == "Device" {
+		return ""
+	}
+	return strings.TrimSuffix(fields[0], ",")
+}
+
+var (
+	amdVendorRe = regexp.MustCompile(`(?i)advanced micro devices|\bamd\b|\bati\b`)
+	// Intel's discrete cards; every other Intel adapter is integrated.
+	intelDiscreteRe = regexp.MustCompile(`(?i)\barc\b|\bdg[12]\b|iris xe max|alchemist|battlemage`)
+	// AMD APU graphics, by codename or by the names used for integrated
+	// Radeon parts ("Radeon Graphics", "Radeon Vega 8", "Radeon 780M").
+	amdIntegratedRe = regexp.MustCompile(`(?i)renoir|cezanne|lucienne|picasso|raven|rembrandt|phoenix|hawk point|raphael|granite ridge|dragon range|barcelo|mendocino|van gogh|strix|krackan|kaveri|carrizo|stoney|kabini|mullins|beema|trinity|richland|godavari|radeon (\(tm\) )?graphics|radeon vega \d+|radeon \d{3}m\b|vega \d+ mobile`)
+	// NVIDIA's system-on-chip graphics.
+	nvidiaIntegratedRe = regexp.MustCompile(`(?i)tegra|nforce|\bion\b`)
+)
+
+// isDiscreteGPU guesses whether an adapter is a separate card from its vendor
+// and names. Virtual, server management and unknown adapters count as
+// integrated so a real GPU is preferred over them.
+func isDiscreteGPU(vendor, device, name string) bool {
+	text := device + " " + name
+	switch vendor {
+	case "NVIDIA":
+		return !nvidiaIntegratedRe.MatchString(text)
+	case "AMD":
+		return !amdIntegratedRe.MatchString(text)
+	case "Intel":
+		return intelDiscreteRe.MatchString(text)
+	}
+	return false
+}
+
+// portableChassis are the SMBIOS chassis types of laptops, convertibles and
+// tablets.
+var portableChassis = map[int]bool{8: true, 9: true, 10: true, 11: true, 14: true, 30: true, 31: true, 32: true}
+
+var (
+	// NVIDIA names laptop chips with an M suffix, e.g. "GA106M".
+	nvidiaMobileChipRe = regexp.MustCompile(`\b(GM|GP|TU|GA|AD|GB)\d{3}M\b`)
+	// AMD's laptop models end in M or S, e.g. "6800M" and "7600S".
+	amdMobileModelRe = regexp.MustCompile(`\b\d{4}[MS]\b`)
+)
+
+// markMobileGPUs flags the discrete adapters that are laptop variants and
+// renames them as the website's benchmark tables do, so a laptop RTX 3060 is
+// not scored as the faster desktop card. An adapter is mobile when its name
+// or chip codename says so, when its PCI ID is listed as mobile in
+// gpunames.tsv, or when chassisType is a laptop's and the adapter is
+// soldered to the mainboard: it then shares the integrated GPU's subsystem
+// ID, where an add-in card in an eGPU enclosure has its maker's.
+func markMobileGPUs(gpus []GPU, chassisType int) {
+	boards := make(map[string]bool)
+	for _, g := range gpus {
+		if !g.Discrete && g.Subsystem != "" {
+			boards[g.Subsystem] = true
+		}
+	}
+	for i := range gpus {
+		g := &gpus[i]
+		if !g.Discrete {
+			continue
+		}
+		switch {
+		case strings.Contains(g.Name, "Laptop"), nvidiaMobileChipRe.MatchString(g.Device), gpuNames.mobile[g.PCIID]:
+		case portableChassis[chassisType] && (len(boards) == 0 || boards[g.Subsystem]):
+		default:
+			continue
+		}
+		g.Mobile = true
+		g.Name = laptopGPUName(*g)
+	}
+}
+
+// laptopGPUName names the laptop variant of g: NVIDIA's get a "Laptop"
+// suffix, AMD's the mobile model from a multi-model bracket.
+func laptopGPUName(g GPU) string {
+	switch {
+	case strings.Contains(g.Name, "Laptop"):
+		return g.Name
+	case g.Vendor == "NVIDIA":
+		return g.Name + " Laptop"
+	case g.Vendor == "AMD" && !amdMobileModelRe.MatchString(g.Name):
+		if m := gpuBracketRe.FindStringSubmatch(applyGPUNameRules(g.Device)); m != nil {
+			for _, model := range gpuBracketModels(m[1]) {
+				if amdMobileModelRe.MatchString(model) {
+					if !strings.Contains(model, "AMD") {
+						model = "AMD " + model
+					}
+					return model
+				}
+			}
+		}
+	}
+	return g.Name
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	_ "embed"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// gpuNamesTable holds the GPU name rules, edited by hand. See the comments
+// in the file for its format.
+//
+//go:embed gpunames.tsv
+var gpuNamesTable string
+
+// gpuNameRule rewrites part of a GPU description.
+type gpuNameRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// gpuNameRules is the parsed rule table.
+type gpuNameRules struct {
+	version int
+	pci     map[string]string // "10de:2504" to a name
+	mobile  map[string]bool   // PCI IDs only used in laptops
+	rules   []gpuNameRule
+}
+
+var gpuNames = loadGPUNameRules()
+
+func loadGPUNameRules() gpuNameRules {
+	t := gpuNameRules{pci: make(map[string]string), mobile: make(map[string]bool)}
+	for _, line := range strings.Split(gpuNamesTable, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		switch {
+		case fields[0] == "version" && len(fields) == 2:
+			t.version, _ = strconv.Atoi(fields[1])
+		case fields[0] == "pci" && len(fields) == 3:
+			t.pci[strings.ToLower(fields[1])] = fields[2]
+		case fields[0] == "mobile" && len(fields) == 2:
+			t.mobile[strings.ToLower(fields[1])] = true
+		case fields[0] == "re" && (len(fields) == 2 || len(fields) == 3):
+			rule := gpuNameRule{re: regexp.MustCompile(fields[1])}
+			if len(fields) == 3 {
+				rule.replacement = fields[2]
+			}
+			t.rules = append(t.rules, rule)
+		}
+	}
+	return t
+}
+
+var gpuBracketRe = regexp.MustCompile(`\[(.+?)\]`)
+
+// normalizeGPUName turns a GPU description into the marketing name the
+// website lists, e.g. "NVIDIA Corporation GA104 [GeForce RTX 3070 Lite Hash
+// Rate] (rev a1)" into "NVIDIA GeForce RTX 3070". pciID ("vendor:device")
+// may be empty. Every platform's detector runs its names through it so the
+// same GPU gets the same name everywhere.
+func normalizeGPUName(description, pciID string) string {
+	if name, ok := gpuNames.pci[strings.ToLower(pciID)]; ok {
+		return name
+	}
+	name := applyGPUNameRules(description)
+
+	// The bracket names the model after a chip codename, e.g.
+	// "NVIDIA GA106 [GeForce RTX 3060]"; the vendor goes in front of it
+	if m := gpuBracketRe.FindStringSubmatch(name); m != nil {
+		vendor := gpuVendor(name)
+		var candidates []string
+		for _, model := range gpuBracketModels(m[1]) {
+			if vendor != "" && !strings.Contains(strings.ToLower(model), strings.ToLower(vendor)) {
+				model = vendor + " " + model
+			}
+			candidates = append(candidates, model)
+		}
+		name = candidates[0]
+		for _, c := range candidates {
+			if listed := siteGPUName(c); listed != "" {
+				name = listed
+				break
+			}
+		}
+	}
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// applyGPUNameRules runs the table's regular expressions over description.
+func applyGPUNameRules(description string) string {
+	for _, r := range gpuNames.rules {
+		description = r.re.ReplaceAllString(description, r.replacement)
+	}
+	return description
+}
+
+// gpuBracketModels splits a multi-model bracket such as
+// "Radeon RX 6700/6700 XT/6750 XT / 6800M/6850M XT" into full model names,
+// repeating the series ("Radeon RX") for models given only by number.
+func gpuBracketModels(bracket string) []string {
+	var models []string
+	series := ""
+	for i, model := range strings.Split(bracket, "/") {
+		model = strings.TrimSpace(model)
+		if model == "" {
+			continue
+		}
+		if i == 0 {
+			if at := strings.IndexFunc(model, unicode.IsDigit); at > 0 && model[at-1] == ' ' {
+				series = model[:at]
+			}
+		} else if unicode.IsDigit(rune(model[0])) {
+			model = series + model
+		}
+		models = append(models, model)
+	}
+	if len(models) == 0 {
+		return []string{bracket}
+	}
+	return models
+}
+
+// siteGPUName returns the website's spelling of name if it lists that GPU.
+func siteGPUName(name string) string {
+	for _, listed := range gpuScores.names {
+		if strings.EqualFold(listed, name) {
+			return listed
+		}
+	}
+	return ""
+}
@@ -19,15 +19,27 @@ func hasCommand(name string) bool {
 
 func runGUI() {
 	if !hasDisplay() {
-		// No display server — just detect, print specs code, and exit
+		// No display server — detect, print the specs code and a QR code of
+		// the link for a phone, and exit
 		result := detectSpecs()
-		code := encodeSpecs(result.Specs)
+		if !confirmSend(result, askInTerminal) {
+			fmt.Println("Cancelled, nothing was sent.")
+			return
+		}
+		code := encodeResult(result)
 		fmt.Println(code)
-		if len(result.Errors) > 0 {
-			for _, e := range result.Errors {
+		if len(result.Warnings) > 0 {
+			for _, e := range warningMessages(result.Warnings) {
 				fmt.Printf("warning: %s\n", e)
 			}
 		}
+		url := shareURL(result)
+		fmt.Println()
+		fmt.Println("Scan this QR code with your phone to open your specs:")
+		if err := printQR(os.Stdout, url, false); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not draw QR code: %v\n", err)
+		}
+		fmt.Println(url)
 		return
 	}
 
@@ -51,20 +63,26 @@ func runWithZenity() {
 	progress.Start()
 
 	result := detectSpecs()
-	code := encodeSpecs(result.Specs)
-	copyToClipboard(code)
-
-	url := getURL(result.Specs)
-	openBrowser(url)
 
 	if progress.Process != nil {
 		progress.Process.Kill()
 	}
+	if !confirmSend(result, askWithZenity) {
+		exec.Command("zenity", "--info", "--title=DoINeedAnUpgrade",
+			"--text=Cancelled, nothing was copied or sent.").Run()
+		return
+	}
+
+	code := encodeResult(result)
+	copyToClipboard(code)
+
+	url := shareURL(result)
+	openBrowser(url)
 
 	msg := "Hardware scan complete!\n\nYour specs have been copied to clipboard and the browser is opening."
-	if len(result.Errors) > 0 {
+	if len(result.Warnings) > 0 {
 		msg += "\n\nWarnings:"
-		for _, e := range result.Errors {
+		for _, e := range warningMessages(result.Warnings) {
 			msg += "\n- " + e
 		}
 	}
@@ -74,21 +92,54 @@ func runWithZenity() {
 		"--timeout=3").Run()
 }
 
+// askWithZenity shows the payload preview in a question dialog.
+func askWithZenity(preview string) bool {
+	err := exec.Command("zenity", "--question",
+		"--title=DoINeedAnUpgrade",
+		"--ok-label=Send", "--cancel-label=Cancel",
+		"--text="+previewMessage(preview)).Run()
+	return err == nil
+}
+
+// askWithKdialog shows the payload preview in a yes/no dialog.
+func askWithKdialog(preview string) bool {
+	err := exec.Command("kdialog", "--title", "DoINeedAnUpgrade",
+		"--yes-label", "Send", "--no-label", "Cancel",
+		"--yesno", previewMessage(preview)).Run()
+	return err == nil
+}
+
+// askUnavailable refuses to send when a preview is required but there is no
+// dialog to show it in.
+func askUnavailable(string) bool {
+	exec.Command("notify-send", "DoINeedAnUpgrade",
+		"Your settings require approving the specs before sending. Run the scanner with --terminal to review them.").Run()
+	return false
+}
+
+func previewMessage(preview string) string {
+	return "The following will be copied to the clipboard and sent to " + baseURL + ":\n\n" + preview
+}
+
 func runWithKdialog() {
 	// Show passive popup as progress indicator
 	exec.Command("kdialog", "--passivepopup", "Scanning your hardware...", "3").Start()
 
 	result := detectSpecs()
-	code := encodeSpecs(result.Specs)
+	if !confirmSend(result, askWithKdialog) {
+		exec.Command("kdialog", "--msgbox", "Cancelled, nothing was copied or sent.").Run()
+		return
+	}
+	code := encodeResult(result)
 	copyToClipboard(code)
 
-	url := getURL(result.Specs)
+	url := shareURL(result)
 	openBrowser(url)
 
 	msg := "Hardware scan complete!\n\nYour specs have been copied to clipboard and the browser is opening."
-	if len(result.Errors) > 0 {
+	if len(result.Warnings) > 0 {
 		msg += "\n\nWarnings:"
-		for _, e := range result.Errors {
+		for _, e := range warningMessages(result.Warnings) {
 			msg += "\n- " + e
 		}
 	}
@@ -99,14 +150,17 @@ func runWithNotifySend() {
 	exec.Command("notify-send", "DoINeedAnUpgrade", "Scanning your hardware...").Run()
 
 	result := detectSpecs()
-	code := encodeSpecs(result.Specs)
+	if !confirmSend(result, askUnavailable) {
+		return
+	}
+	code := encodeResult(result)
 	copyToClipboard(code)
 
-	url := getURL(result.Specs)
+	url := shareURL(result)
 	openBrowser(url)
 
 	notifyMsg := "Hardware scan complete! Your browser is opening."
-	if len(result.Errors) > 0 {
+	if len(result.Warnings) > 0 {
 		notifyMsg += " (with warnings)"
 	}
 	exec.Command("notify-send", "DoINeedAnUpgrade", notifyMsg).Run()
@@ -114,9 +168,12 @@ func runWithNotifySend() {
 
 func runSilent() {
 	result := detectSpecs()
-	code := encodeSpecs(result.Specs)
+	if !confirmSend(result, func(string) bool { return false }) {
+		return
+	}
+	code := encodeResult(result)
 	copyToClipboard(code)
 
-	url := getURL(result.Specs)
+	url := shareURL(result)
 	openBrowser(url)
 }
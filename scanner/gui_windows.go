@@ -14,7 +14,10 @@ var (
 
 const (
 	MB_OK              = 0x00000000
+	MB_YESNO           = 0x00000004
+	MB_ICONQUESTION    = 0x00000020
 	MB_ICONINFORMATION = 0x00000040
+	IDYES              = 6
 )
 
 func showMessage(title, message string) {
@@ -28,19 +31,41 @@ func showMessage(title, message string) {
 	)
 }
 
+// askYesNo shows a Yes/No message box and reports whether Yes was chosen.
+func askYesNo(title, message string) bool {
+	titlePtr, _ := syscall.UTF16PtrFromString(title)
+	messagePtr, _ := syscall.UTF16PtrFromString(message)
+	ret, _, _ := procMessageBoxW.Call(
+		0,
+		uintptr(unsafe.Pointer(messagePtr)),
+		uintptr(unsafe.Pointer(titlePtr)),
+		MB_YESNO|MB_ICONQUESTION,
+	)
+	return ret == IDYES
+}
+
 func runGUI() {
 	result := detectSpecs()
 
-	code := encodeSpecs(result.Specs)
+	approved := confirmSend(result, func(preview string) bool {
+		return askYesNo("DoINeedAnUpgrade",
+			"The following will be copied to the clipboard and sent to "+baseURL+":\n\n"+preview+"\n\nSend these specs?")
+	})
+	if !approved {
+		showMessage("DoINeedAnUpgrade", "Cancelled, nothing was copied or sent.")
+		return
+	}
+
+	code := encodeResult(result)
 	copyToClipboard(code)
 
-	url := getURL(result.Specs)
+	url := shareURL(result)
 	openBrowser(url)
 
 	msg := "Hardware scan complete!\n\nYour specs have been copied to clipboard and the browser is opening."
-	if len(result.Errors) > 0 {
+	if len(result.Warnings) > 0 {
 		msg += "\n\nWarnings:"
-		for _, e := range result.Errors {
+		for _, e := range warningMessages(result.Warnings) {
 			msg += "\n- " + e
 		}
 	}
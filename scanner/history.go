@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// historyLimit caps how many scans are kept; older ones are dropped.
+const historyLimit = 500
+
+// storageChangeGB is the smallest change in free storage worth reporting,
+// so normal disk churn is not listed on every scan.
+const storageChangeGB = 10
+
+// historyEnabled is false when the config or the global --no-history flag
+// turns history off. Every scan of this machine, including the GUI and the
+// terminal mode, is recorded through recordScan, which checks it.
+var historyEnabled = true
+
+// historyEntry is one saved scan. Specs are stored unredacted; the file never
+// leaves the machine.
+type historyEntry struct {
+	Time    time.Time `json:"time"`
+	Scanner string    `json:"scanner"`
+	Specs   Specs     `json:"specs"`
+}
+
+// dataDir is where the scanner keeps local data: $XDG_DATA_HOME on Linux,
+// Application Support on macOS and %LOCALAPPDATA% on Windows. DINAU_DATA_DIR
+// overrides it.
+func dataDir() (string, error) {
+	if dir := os.Getenv("DINAU_DATA_DIR"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	var base string
+	switch runtime.GOOS {
+	case "darwin":
+		base = filepath.Join(home, "Library", "Application Support")
+	case "windows":
+		if base = os.Getenv("LOCALAPPDATA"); base == "" {
+			base = filepath.Join(home, "AppData", "Local")
+		}
+	default:
+		if base = os.Getenv("XDG_DATA_HOME"); base == "" {
+			base = filepath.Join(home, ".local", "share")
+		}
+	}
+	return filepath.Join(base, "doineedanupgrade"), nil
+}
+
+func historyPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// loadHistory returns saved scans, oldest first. Unreadable lines are
+// skipped so one bad write does not lose the rest.
+func loadHistory() ([]historyEntry, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []historyEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e historyEntry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// saveHistory rewrites the history file with the newest historyLimit entries.
+func saveHistory(entries []historyEntry) error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if len(entries) > historyLimit {
+		entries = entries[len(entries)-historyLimit:]
+	}
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// recordScan saves result to the history and returns the previous scan, or
+// nil if there was none. Failures are reported but never stop a scan.
+func recordScan(result DetectionResult) *historyEntry {
+	if !historyEnabled {
+		return nil
+	}
+	entries, err := loadHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not read scan history: %v\n", err)
+	}
+	var previous *historyEntry
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		previous = &last
+	}
+	entries = append(entries, historyEntry{Time: time.Now().UTC(), Scanner: version, Specs: result.Specs})
+	if err := saveHistory(entries); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not save scan history: %v\n", err)
+	}
+	return previous
+}
+
+// specChanges describes what differs between two scans, in the order of
+// the Specs fields.
+func specChanges(old, cur Specs) []string {
+	var changes []string
+	text := func(label, a, b string) {
+		if a != b && a != "" && b != "" {
+			changes = append(changes, fmt.Sprintf("%s: %s → %s", label, a, b))
+		}
+	}
+	number := func(label string, a, b int, unit, more, less string) {
+		if a == 0 || b == 0 || a == b {
+			return
+		}
+		note := more
+		if b < a {
+			note = less
+		}
+		changes = append(changes, fmt.Sprintf("%s: %d → %d%s (%+d, %s)", label, a, b, unit, b-a, note))
+	}
+
+	text("OS", old.OS, cur.OS)
+	text("CPU", old.CPU, cur.CPU)
+	if old.CPU == cur.CPU {
+		number("CPU cores", old.CPUCores, cur.CPUCores, "", "more cores", "fewer cores")
+	}
+	text("GPU", old.GPU, cur.GPU)
+	number("RAM", old.RAMGB, cur.RAMGB, " GB", "RAM added", "RAM removed")
+	if d := cur.StorageGB - old.StorageGB; d >= storageChangeGB || d <= -storageChangeGB {
+		number("Free storage", old.StorageGB, cur.StorageGB, " GB", "space freed", "disk fuller")
+	}
+	return changes
+}
+
+// printChanges writes the change summary against the previous scan.
+func printChanges(w io.Writer, previous *historyEntry, cur Specs) {
+	if previous == nil {
+		return
+	}
+	changes := specChanges(previous.Specs, cur)
+	when := previous.Time.Local().Format("2006-01-02 15:04")
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "No changes since the last scan (%s).\n", when)
+		return
+	}
+	fmt.Fprintf(w, "Changes since the last scan (%s):\n", when)
+	for _, c := range changes {
+		fmt.Fprintf(w, "  - %s\n", c)
+	}
+}
+
+// runHistory lists saved scans, optionally with what changed between them.
+func runHistory(args []string) int {
+	fs := newFlagSet("history")
+	limit := fs.Int("n", 20, "show the last n scans (0 for all)")
+	changes := fs.Bool("changes", false, "show what changed between consecutive scans")
+	asJSON := fs.Bool("json", false, "print the entries as JSON lines")
+	clear := fs.Bool("clear", false, "delete the scan history")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	path, err := historyPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if *clear {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		fmt.Println("Scan history cleared.")
+		return exitOK
+	}
+
+	entries, err := loadHistory()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "No scans saved yet (%s).\n", path)
+		return exitOK
+	}
+	start := 0
+	if *limit > 0 && len(entries) > *limit {
+		start = len(entries) - *limit
+	}
+
+	for i := start; i < len(entries); i++ {
+		e := entries[i]
+		if *asJSON {
+			line, _ := json.Marshal(e)
+			fmt.Println(string(line))
+			continue
+		}
+		s := e.Specs
+		gpu := s.GPU
+		if gpu == "" {
+			gpu = "no GPU detected"
+		}
+		fmt.Printf("%s  %s | %s | %d GB RAM | %d GB free\n",
+			e.Time.Local().Format("2006-01-02 15:04"), s.CPU, gpu, s.RAMGB, s.StorageGB)
+		if *changes && i > 0 {
+			for _, c := range specChanges(entries[i-1].Specs, s) {
+				fmt.Printf("                  %s\n", c)
+			}
+		}
+	}
+	if !*asJSON {
+		fmt.Fprintf(os.Stderr, "%d of %d scans, saved in %s\n", len(entries)-start, len(entries), filepath.Dir(path))
+	}
+	return exitOK
+}
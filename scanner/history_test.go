@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordScanHonoursHistoryEnabled(t *testing.T) {
+	t.Setenv("DINAU_DATA_DIR", t.TempDir())
+	saved := historyEnabled
+	t.Cleanup(func() { historyEnabled = saved })
+	result := DetectionResult{Specs: Specs{OS: "Fedora 40", CPU: "Intel Core i5-13600K", RAMGB: 32}, live: true}
+
+	historyEnabled = false
+	recordScan(result)
+	if entries, err := loadHistory(); err != nil || len(entries) != 0 {
+		t.Fatalf("history disabled: %d entries saved (%v), want none", len(entries), err)
+	}
+
+	historyEnabled = true
+	recordScan(result)
+	if entries, err := loadHistory(); err != nil || len(entries) != 1 {
+		t.Fatalf("history enabled: %d entries saved (%v), want 1", len(entries), err)
+	}
+}
+
+// TestOnlyUserScansRecorded checks that serve's cache refreshes and
+// `compare scan` leave the history alone while `scan` records.
+func TestOnlyUserScansRecorded(t *testing.T) {
+	t.Setenv("DINAU_DATA_DIR", t.TempDir())
+	savedEnabled, savedRegistry := historyEnabled, defaultRegistry
+	t.Cleanup(func() { historyEnabled, defaultRegistry = savedEnabled, savedRegistry })
+	historyEnabled = true
+	env, err := fixtureEnv(filepath.Join("testdata", "fixtures", "ryzen-desktop"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultRegistry = NewRegistry(linuxDetectors(env)...)
+	sf := scanFlags{timeout: defaultDetectOptions.Timeout, probeTimeout: defaultDetectOptions.ProbeTimeout}
+
+	cache := &scanCache{flags: &sf}
+	for i := 0; i < 2; i++ {
+		if _, err := cache.get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := loadCompareSource("scan", &sf); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := loadHistory(); err != nil || len(entries) != 0 {
+		t.Fatalf("serve and compare: %d entries saved (%v), want none", len(entries), err)
+	}
+
+	if _, err := sf.detect(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := loadHistory(); err != nil || len(entries) != 1 {
+		t.Fatalf("scan: %d entries saved (%v), want 1", len(entries), err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// importMaxBytes matches MAX_BODY_SIZE in src/app/api/import/route.ts.
+const importMaxBytes = 4096
+
+var ErrPayloadTooLarge = errors.New("payload exceeds the import size limit")
+
+// importClient posts specs to the site's /api/import endpoint, which returns a
+// short-lived token to open as ?import=TOKEN. This is the same flow the shell
+// scripts from /api/scan and /api/scan.ps1 use.
+type importClient struct {
+	baseURL string
+	http    *http.Client
+	// attempts is the number of tries, including the first.
+	attempts int
+	// backoff is the wait before the first retry; it doubles on each retry.
+	backoff time.Duration
+}
+
+func newImportClient(baseURL string) importClient {
+	return importClient{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		http:     &http.Client{Timeout: 5 * time.Second},
+		attempts: 3,
+		backoff:  500 * time.Millisecond,
+	}
+}
+
+// importBody returns the JSON to post, dropping extended sections if the full
+// payload is over the size limit.
+func importBody(p Payload) ([]byte, error) {
+	body, err := p.marshal()
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > importMaxBytes && len(p.Ext) > 0 {
+		p.Ext = nil
+		if body, err = p.marshal(); err != nil {
+			return nil, err
+		}
+	}
+	if len(body) > importMaxBytes {
+		return nil, fmt.Errorf("%w (%d of %d bytes)", ErrPayloadTooLarge, len(body), importMaxBytes)
+	}
+	return body, nil
+}
+
+// retryableError marks failures worth another attempt: network errors,
+// timeouts, rate limiting and server errors.
+type retryableError struct{ err error }
+
+func (e retryableError) Error() string { return e.err.Error() }
+func (e retryableError) Unwrap() error { return e.err }
+
+// importSpecs posts the payload and returns the import token.
+func (c importClient) importSpecs(ctx context.Context, p Payload) (string, error) {
+	body, err := importBody(p)
+	if err != nil {
+		return "", err
+	}
+
+	wait := c.backoff
+	for attempt := 1; ; attempt++ {
+		token, err := c.post(ctx, body)
+		var retryable retryableError
+		if err == nil || !errors.As(err, &retryable) || attempt >= c.attempts {
+			return token, err
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (c importClient) post(ctx context.Context, body []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/import", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", retryableError{err}
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Token string `json:"token"`
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	json.Unmarshal(data, &result)
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("import failed: %s", resp.Status)
+		if result.Error != "" {
+			err = fmt.Errorf("import failed: %s: %s", resp.Status, result.Error)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return "", retryableError{err}
+		}
+		return "", err
+	}
+	if result.Token == "" {
+		return "", errors.New("import response has no token")
+	}
+	return result.Token, nil
+}
+
+// importURL is the site link that loads specs stored under token.
+func (c importClient) importURL(token string) string {
+	return c.baseURL + "?import=" + url.QueryEscape(token)
+}
+
+// shareURL returns the link to open in the browser: an ?import= token link
+// when the POST succeeds, otherwise the ?specs= link.
+func shareURL(result DetectionResult) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	client := newImportClient(baseURL)
+	token, err := client.importSpecs(ctx, newPayload(result))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not upload specs (%v), using a ?specs= link instead\n", err)
+		return getURL(result)
+	}
+	return client.importURL(token)
+}
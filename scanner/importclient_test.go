@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testImportSpecs = Specs{OS: "Ubuntu 24.04", CPU: "AMD Ryzen 7 5800X", CPUCores: 8, RAMGB: 32}
+
+// importServer answers /api/import with the given status codes in turn,
+// repeating the last one, and counts the requests.
+func importServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if r.Method != http.MethodPost || r.URL.Path != "/api/import" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if _, err := parsePayloadJSON(body); err != nil {
+			t.Errorf("posted payload does not parse: %v", err)
+		}
+		status := statuses[min(n, len(statuses))-1]
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			io.WriteString(w, `{"token":"tok en"}`)
+		} else {
+			io.WriteString(w, `{"error":"nope"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func testImportClient(base string) importClient {
+	c := newImportClient(base)
+	c.backoff = time.Millisecond
+	return c
+}
+
+func TestImportSpecsRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+		retryable bool
+	}{
+		{"ok", []int{200}, 1, false, false},
+		{"server error then ok", []int{500, 503, 200}, 3, false, false},
+		{"rate limited then ok", []int{429, 200}, 2, false, false},
+		{"server errors exhaust attempts", []int{502}, 3, true, true},
+		{"rate limit exhausts attempts", []int{429}, 3, true, true},
+		{"bad request is not retried", []int{400, 200}, 1, true, false},
+		{"payload too large is not retried", []int{413, 200}, 1, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := importServer(t, tt.statuses...)
+			token, err := testImportClient(srv.URL).importSpecs(context.Background(), Payload{Specs: testImportSpecs})
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("%d requests, want %d", got, tt.wantCalls)
+			}
+			if tt.wantErr {
+				var retryable retryableError
+				if err == nil {
+					t.Fatalf("token %q, want an error", token)
+				}
+				if errors.As(err, &retryable) != tt.retryable {
+					t.Errorf("err = %v, retryable %t, want %t", err, !tt.retryable, tt.retryable)
+				}
+				if !strings.Contains(err.Error(), "nope") {
+					t.Errorf("err = %v, want the server's error message", err)
+				}
+				return
+			}
+			if err != nil || token != "tok en" {
+				t.Fatalf("importSpecs = %q, %v; want the token", token, err)
+			}
+		})
+	}
+}
+
+func TestImportSpecsNetworkError(t *testing.T) {
+	srv, _ := importServer(t, http.StatusOK)
+	srv.Close()
+	_, err := testImportClient(srv.URL).importSpecs(context.Background(), Payload{Specs: testImportSpecs})
+	var retryable retryableError
+	if !errors.As(err, &retryable) {
+		t.Errorf("err = %v, want a retryable error", err)
+	}
+}
+
+func TestImportSpecsMissingToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+	if token, err := testImportClient(srv.URL).importSpecs(context.Background(), Payload{Specs: testImportSpecs}); err == nil {
+		t.Errorf("token %q, want an error", token)
+	}
+}
+
+func TestImportBodyPreflight(t *testing.T) {
+	withExt := Payload{Specs: testImportSpecs}
+	withExt.setExt("padding", strings.Repeat("x", importMaxBytes))
+	body, err := importBody(withExt)
+	if err != nil {
+		t.Fatalf("importBody: %v", err)
+	}
+	var posted map[string]json.RawMessage
+	json.Unmarshal(body, &posted)
+	if _, ok := posted["ext"]; ok || len(body) > importMaxBytes {
+		t.Errorf("extended sections kept in a %d byte body", len(body))
+	}
+
+	oversized := testImportSpecs
+	oversized.CPU = strings.Repeat("x", importMaxBytes)
+	srv, calls := importServer(t, http.StatusOK)
+	if _, err := testImportClient(srv.URL).importSpecs(context.Background(), Payload{Specs: oversized}); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("err = %v, want %v", err, ErrPayloadTooLarge)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("oversized payload was posted")
+	}
+}
+
+func TestShareURL(t *testing.T) {
+	saved := baseURL
+	t.Cleanup(func() { baseURL = saved })
+	result := DetectionResult{Specs: testImportSpecs}
+
+	srv, _ := importServer(t, http.StatusOK)
+	baseURL = srv.URL
+	if got, want := shareURL(result), srv.URL+"?import=tok+en"; got != want {
+		t.Errorf("shareURL = %q, want %q", got, want)
+	}
+
+	srv, _ = importServer(t, http.StatusBadRequest)
+	baseURL = srv.URL
+	got := shareURL(result)
+	param, ok := strings.CutPrefix(got, srv.URL+"?specs=")
+	if !ok {
+		t.Fatalf("shareURL = %q, want a ?specs= fallback link", got)
+	}
+	p, err := decodeSpecsParam(param)
+	if err != nil || p.Specs.CPU != testImportSpecs.CPU {
+		t.Errorf("fallback link decodes to %+v, %v", p.Specs, err)
+	}
+}
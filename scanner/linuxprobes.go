@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// linuxProbes reads Linux hardware information from a probeEnv. It has no
+// build tag so fixtures can be replayed on any OS.
+type linuxProbes struct {
+	env probeEnv
+}
+
+func linuxDetectors(env probeEnv) []Detector {
+	p := linuxProbes{env: env}
+	return []Detector{
+		newDetector("os-release", ComponentOS, p.detectOSRelease),
+		newDetector("uname", ComponentOS, p.detectUname),
+		newDetector("cpuinfo-model", ComponentCPU, p.detectCPUModel),
+		newDetector("cpuinfo-cores", ComponentCPUCores, p.detectPhysicalCores),
+		newDetector("nproc", ComponentCPUCores, p.detectNproc),
+		newDetector("cpufreq-max", ComponentCPUSpeed, p.detectCPUFreqMax),
+		newDetector("cpuinfo-mhz", ComponentCPUSpeed, p.detectCPUInfoMHz),
+		newDetector("sysfs-pci", ComponentGPU, p.detectSysfsGPU),
+		newDetector("lspci", ComponentGPU, p.detectLspciGPU),
+		newDetector("sysfs-pci-ids", ComponentGPU, p.detectSysfsGPUByID),
+		newDetector("meminfo", ComponentRAM, p.detectMemInfo),
+		newDetector("df", ComponentStorage, p.detectDfStorage),
+	}
+}
+
+func (p linuxProbes) detectOSRelease(ctx context.Context) (Reading, error) {
+	data, err := p.env.readFile("/etc/os-release")
+	if err != nil {
+		return Reading{}, newWarning(CodeOSUndetected, SeverityError, ComponentOS)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "PRETTY_NAME=") {
+			if name := strings.Trim(strings.TrimPrefix(line, "PRETTY_NAME="), `"`); name != "" {
+				return Reading{Value: name, Source: SourceFile, Confidence: ConfidenceExact}, nil
+			}
+		}
+	}
+	return Reading{}, newWarning(CodeOSUndetected, SeverityError, ComponentOS)
+}
+
+func (p linuxProbes) detectUname(ctx context.Context) (Reading, error) {
+	out, err := p.env.output(ctx, "uname", "-sr")
+	if err != nil || strings.TrimSpace(string(out)) == "" {
+		return Reading{}, newWarning(CodeOSUndetected, SeverityError, ComponentOS)
+	}
+	return Reading{Value: strings.TrimSpace(string(out)), Source: SourceCommand, Confidence: ConfidenceHigh}, nil
+}
+
+func (p linuxProbes) detectCPUModel(ctx context.Context) (Reading, error) {
+	if data, err := p.env.readFile("/proc/cpuinfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "model name") {
+				parts := strings.SplitN(line, ":", 2)
+				if len(parts) == 2 {
+					if name := cleanCPUName(strings.TrimSpace(parts[1])); name != "" {
+						return Reading{Value: name, Source: SourceProcfs, Confidence: ConfidenceExact}, nil
+					}
+				}
+			}
+		}
+	}
+	return Reading{}, newWarning(CodeCPUNameUndetected, SeverityError, ComponentCPU)
+}
+
+// detectPhysicalCores counts unique (physical_id, core_id) pairs.
+func (p linuxProbes) detectPhysicalCores(ctx context.Context) (Reading, error) {
+	if data, err := p.env.readFile("/proc/cpuinfo"); err == nil {
+		if cores := countPhysicalCores(string(data)); cores > 0 {
+			return Reading{Value: cores, Source: SourceProcfs, Confidence: ConfidenceExact}, nil
+		}
+	}
+	return Reading{}, newWarning(CodeCPUCoresUndetected, SeverityError, ComponentCPUCores)
+}
+
+// detectNproc falls back to nproc, which gives logical threads.
+func (p linuxProbes) detectNproc(ctx context.Context) (Reading, error) {
+	out, _ := p.env.output(ctx, "nproc")
+	cores, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	if cores <= 0 {
+		return Reading{}, newWarning(CodeCPUCoresUndetected, SeverityError, ComponentCPUCores)
+	}
+	return Reading{
+		Value:      cores,
+		Source:     SourceCommand,
+		Confidence: ConfidenceEstimated,
+		Warnings:   []Warning{newWarning(CodeCPUCoresLogical, SeverityWarning, ComponentCPUCores)},
+	}, nil
+}
+
+func (p linuxProbes) detectCPUFreqMax(ctx context.Context) (Reading, error) {
+	if data, err := p.env.readFile("/sys/devices/system/cpu/cpu0/cpufreq/cpuinfo_max_freq"); err == nil {
+		if kHz, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err == nil && kHz > 0 {
+			return Reading{Value: float64(kHz) / 1e6, Source: SourceSysfs, Confidence: ConfidenceExact}, nil // kHz to GHz
+		}
+	}
+	return Reading{}, newWarning(CodeCPUSpeedUndetected, SeverityError, ComponentCPUSpeed)
+}
+
+func (p linuxProbes) detectCPUInfoMHz(ctx context.Context) (Reading, error) {
+	if data, err := p.env.readFile("/proc/cpuinfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "cpu MHz") {
+				parts := strings.SplitN(line, ":", 2)
+				if len(parts) == 2 {
+					if mhz, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err == nil && mhz > 0 {
+						return Reading{Value: mhz / 1000.0, Source: SourceProcfs, Confidence: ConfidenceEstimated}, nil
+					}
+				}
+			}
+		}
+	}
+	return Reading{}, newWarning(CodeCPUSpeedUndetected, SeverityError, ComponentCPUSpeed)
+}
+
+// detectSysfsGPU lists every display controller (PCI class 0x03) under
+// /sys/bus/pci/devices and names it from the embedded PCI ID table, so GPUs
+// are found without pciutils, e.g. in containers and Flatpak sandboxes.
+func (p linuxProbes) detectSysfsGPU(ctx context.Context) (Reading, error) {
+	gpus, unnamed, err := p.sysfsGPUs()
+	if err != nil {
+		return Reading{}, err
+	}
+	// A device the embedded table cannot name is better described by lspci
+	if len(unnamed) > 0 {
+		return Reading{}, newWarning(CodeGPUUnnamedDevice, SeverityWarning, ComponentGPU).with("device", strings.Join(unnamed, ", "))
+	}
+	markMobileGPUs(gpus, p.chassisType())
+	p.addVRAM(ctx, gpus)
+	return Reading{Value: gpus, Source: SourceSysfs, Confidence: ConfidenceHigh}, nil
+}
+
+// detectSysfsGPUByID is the last resort when lspci is missing and the
+// embedded table does not name every adapter: it reports them with lspci's
+// "Device xxxx" placeholder for the unnamed ones.
+func (p linuxProbes) detectSysfsGPUByID(ctx context.Context) (Reading, error) {
+	gpus, unnamed, err := p.sysfsGPUs()
+	if err != nil {
+		// Leave the sysfs-pci or lspci failure as the one reported
+		return Reading{}, newWarning(CodeProbeNotApplicable, SeverityInfo, ComponentGPU).with("detector", "sysfs-pci-ids")
+	}
+	markMobileGPUs(gpus, p.chassisType())
+	p.addVRAM(ctx, gpus)
+	reading := Reading{Value: gpus, Source: SourceSysfs, Confidence: ConfidenceHigh}
+	if len(unnamed) > 0 {
+		reading.Confidence = ConfidenceEstimated
+		reading.Warnings = []Warning{newWarning(CodeGPUUnnamedDevice, SeverityWarning, ComponentGPU).with("device", strings.Join(unnamed, ", "))}
+	}
+	return reading, nil
+}
+
+// sysfsGPUs lists the display controllers in /sys/bus/pci/devices and the
+// "vendor:device" IDs of those neither pciids.gz nor gpunames.tsv names.
+func (p linuxProbes) sysfsGPUs() ([]GPU, []string, error) {
+	entries, err := p.env.readDir("/sys/bus/pci/devices")
+	if err != nil {
+		return nil, nil, newWarning(CodeGPUUndetected, SeverityError, ComponentGPU).withCause(err)
+	}
+	var gpus []GPU
+	var unnamed []string
+	for _, entry := range entries {
+		slot := entry.Name()
+		// Attribute files hold "0x"-prefixed hex, e.g. "0x030000" for class
+		id := func(attr string) string {
+			data, _ := p.env.readFile("/sys/bus/pci/devices/" + slot + "/" + attr)
+			return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+		}
+		if !strings.HasPrefix(id("class"), "03") {
+			continue
+		}
+		vendor, device := id("vendor"), id("device")
+		if vendor == "" || device == "" {
+			continue
+		}
+		description, named := pciIDs().describe(vendor, device)
+		if _, listed := gpuNames.pci[vendor+":"+device]; !named && !listed {
+			unnamed = append(unnamed, vendor+":"+device)
+		}
+		gpu := GPU{
+			Name:    normalizeGPUName(description, vendor+":"+device),
+			Vendor:  gpuVendor(description),
+			Device:  description,
+			Slot:    slot,
+			PCIID:   vendor + ":" + device,
+			BootVGA: p.pciBootVGA(slot),
+		}
+		if subVendor, subDevice := id("subsystem_vendor"), id("subsystem_device"); subVendor != "" && subDevice != "" {
+			gpu.Subsystem = subVendor + ":" + subDevice
+		}
+		gpu.Discrete = isDiscreteGPU(gpu.Vendor, description, gpu.Name)
+		gpus = append(gpus, gpu)
+	}
+	if len(gpus) == 0 {
+		return nil, nil, newWarning(CodeGPUNoDisplayDevice, SeverityError, ComponentGPU)
+	}
+	return gpus, unnamed, nil
+}
+
+// detectLspciGPU is the fallback when sysfs is not mounted or names a device
+// the embedded table does not know. It lists every display controller lspci
+// reports.
+func (p linuxProbes) detectLspciGPU(ctx context.Context) (Reading, error) {
+	lspciOut, err := p.env.output(ctx, "lspci")
+	if err != nil {
+		return Reading{}, newWarning(CodeGPULspciMissing, SeverityError, ComponentGPU).withCause(err)
+	}
+	var gpus []GPU
+	for _, line := range strings.Split(string(lspciOut), "\n") {
+		// lspci format: "SLOT CLASS: VENDOR DEVICE (rev XX)"
+		// Split on ": " to skip the slot+class prefix (slot uses ":" without space)
+		parts := strings.SplitN(line, ": ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		slot, class, _ := strings.Cut(parts[0], " ")
+		if !isDisplayClass(class) {
+			continue
+		}
+		if strings.Count(slot, ":") == 1 {
+			slot = "0000:" + slot
+		}
+		device := strings.TrimSpace(parts[1])
+		gpu := GPU{
+			Name:    normalizeGPUName(device, ""),
+			Vendor:  gpuVendor(device),
+			Device:  device,
+			Slot:    slot,
+			BootVGA: p.pciBootVGA(slot),
+		}
+		gpu.Discrete = isDiscreteGPU(gpu.Vendor, device, gpu.Name)
+		gpus = append(gpus, gpu)
+	}
+	if len(gpus) == 0 {
+		return Reading{}, newWarning(CodeGPUNoDisplayDevice, SeverityError, ComponentGPU)
+	}
+	markMobileGPUs(gpus, p.chassisType())
+	p.addVRAM(ctx, gpus)
+	return Reading{Value: gpus, Source: SourceLspci, Confidence: ConfidenceHigh}, nil
+}
+
+// isDisplayClass matches lspci's names for PCI class 0x03 devices.
+func isDisplayClass(class string) bool {
+	lower := strings.ToLower(class)
+	return strings.HasPrefix(lower, "vga") || strings.HasPrefix(lower, "3d") || strings.HasPrefix(lower, "display")
+}
+
+// chassisType reads the SMBIOS chassis type, e.g. 3 for a desktop and 10 for
+// a notebook, or 0 where DMI is not available.
+func (p linuxProbes) chassisType() int {
+	data, _ := p.env.readFile("/sys/class/dmi/id/chassis_type")
+	chassis, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return chassis
+}
+
+// addVRAM fills in the video memory of each adapter its driver reports it for.
+func (p linuxProbes) addVRAM(ctx context.Context, gpus []GPU) {
+	for i := range gpus {
+		gpus[i].VRAM = p.gpuVRAM(ctx, gpus[i])
+	}
+}
+
+// gpuVRAM reads an adapter's video memory from its driver: amdgpu's sysfs
+// counters, the NVIDIA driver's procfs or nvidia-smi, or the local memory
+// size xe and i915 expose for Intel's discrete cards. On AMD APUs amdgpu
+// reports the RAM carve-out set in the firmware, which is marked Shared.
+// Intel's integrated GPUs share RAM without a fixed amount, so have none.
+func (p linuxProbes) gpuVRAM(ctx context.Context, g GPU) *VRAM {
+	if g.Slot == "" {
+		return nil
+	}
+	dir := "/sys/bus/pci/devices/" + g.Slot
+	found := func(mb int, detector string, source Source) *VRAM {
+		return &VRAM{MB: mb, Provenance: Provenance{Detector: detector, Source: source, Confidence: ConfidenceExact}}
+	}
+	switch g.Vendor {
+	case "AMD":
+		if mb := p.sysfsMB(dir + "/mem_info_vram_total"); mb > 0 {
+			if g.Discrete {
+				return found(mb, "amdgpu", SourceSysfs)
+			}
+			v := found(mb, "amdgpu-carveout", SourceSysfs)
+			v.Shared = true
+			return v
+		}
+	case "NVIDIA":
+		if mb := p.nvidiaProcVRAM(g.Slot); mb > 0 {
+			return found(mb, "nvidia-procfs", SourceProcfs)
+		}
+		if mb := p.nvidiaSmiVRAM(ctx, g.Slot); mb > 0 {
+			return found(mb, "nvidia-smi", SourceCommand)
+		}
+	case "Intel":
+		if !g.Discrete {
+			return nil
+		}
+		if mb := p.sysfsMB(dir + "/tile0/physical_vram_size_bytes"); mb > 0 {
+			return found(mb, "xe", SourceSysfs)
+		}
+		entries, _ := p.env.readDir(dir + "/drm")
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), "card") {
+				if mb := p.sysfsMB(dir + "/drm/" + e.Name() + "/lmem_total_bytes"); mb > 0 {
+					return found(mb, "i915", SourceSysfs)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sysfsMB reads a sysfs attribute holding a size in bytes, in MiB.
+func (p linuxProbes) sysfsMB(path string) int {
+	data, err := p.env.readFile(path)
+	if err != nil {
+		return 0
+	}
+	size, _ := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	return int(size >> 20)
+}
+
+// nvidiaProcVRAM reads the "Video Memory:" line of the NVIDIA driver's
+// per-GPU information file, where the driver version lists it.
+func (p linuxProbes) nvidiaProcVRAM(slot string) int {
+	data, err := p.env.readFile("/proc/driver/nvidia/gpus/" + slot + "/information")
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) != "Video Memory" {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 2 && strings.EqualFold(fields[1], "MB") {
+			mb, _ := strconv.Atoi(fields[0])
+			return mb
+		}
+	}
+	return 0
+}
+
+// nvidiaSmiVRAM asks nvidia-smi for the memory of the GPU at slot. Its bus
+// IDs have an 8-digit domain, e.g. "00000000:01:00.0".
+func (p linuxProbes) nvidiaSmiVRAM(ctx context.Context, slot string) int {
+	out, err := p.env.output(ctx, "nvidia-smi", "--query-gpu=pci.bus_id,memory.total", "--format=csv,noheader,nounits")
+	if err != nil {
+		return 0
+	}
+	suffix := ":" + strings.ToLower(strings.TrimPrefix(slot, "0000:"))
+	for _, line := range strings.Split(string(out), "\n") {
+		busID, total, ok := strings.Cut(line, ",")
+		if ok && strings.HasSuffix(strings.ToLower(strings.TrimSpace(busID)), suffix) {
+			mb, _ := strconv.Atoi(strings.TrimSpace(total))
+			return mb
+		}
+	}
+	return 0
+}
+
+// pciBootVGA reports whether the firmware used the device at slot for the
+// boot display.
+func (p linuxProbes) pciBootVGA(slot string) bool {
+	data, err := p.env.readFile("/sys/bus/pci/devices/" + slot + "/boot_vga")
+	return err == nil && strings.TrimSpace(string(data)) == "1"
+}
+
+func (p linuxProbes) detectMemInfo(ctx context.Context) (Reading, error) {
+	if data, err := p.env.readFile("/proc/meminfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "MemTotal:") {
+				fields := strings.Fields(line)
+				if len(fields) >= 2 {
+					kb, _ := strconv.ParseInt(fields[1], 10, 64)
+					if gb := int((kb + 524288) / 1048576); gb > 0 { // Round to nearest GB
+						return Reading{Value: gb, Source: SourceProcfs, Confidence: ConfidenceHigh}, nil
+					}
+				}
+				break
+			}
+		}
+	}
+	return Reading{}, newWarning(CodeRAMUndetected, SeverityError, ComponentRAM)
+}
+
+// detectDfStorage reports free space on the root filesystem.
+func (p linuxProbes) detectDfStorage(ctx context.Context) (Reading, error) {
+	dfOut, err := p.env.output(ctx, "df", "-BG", "/")
+	if err != nil {
+		return Reading{}, newWarning(CodeStorageUndetected, SeverityError, ComponentStorage).withCause(err)
+	}
+	lines := strings.Split(string(dfOut), "\n")
+	if len(lines) >= 2 {
+		fields := strings.Fields(lines[1])
+		if len(fields) >= 4 {
+			freeStr := strings.TrimSuffix(fields[3], "G")
+			if free, _ := strconv.Atoi(freeStr); free > 0 {
+				return Reading{Value: free, Source: SourceCommand, Confidence: ConfidenceExact}, nil
+			}
+		}
+	}
+	return Reading{}, newWarning(CodeStorageUnparsed, SeverityError, ComponentStorage)
+}
+
+// countPhysicalCores counts unique (physical_id, core_id) pairs in /proc/cpuinfo content.
+func countPhysicalCores(cpuinfo string) int {
+	type coreKey struct {
+		physicalID string
+		coreID     string
+	}
+	seen := make(map[coreKey]bool)
+	var currentPhysicalID, currentCoreID string
+
+	for _, line := range strings.Split(cpuinfo, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "physical id") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				currentPhysicalID = strings.TrimSpace(parts[1])
+			}
+		} else if strings.HasPrefix(line, "core id") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) == 2 {
+				currentCoreID = strings.TrimSpace(parts[1])
+				seen[coreKey{currentPhysicalID, currentCoreID}] = true
+			}
+		}
+	}
+
+	return len(seen)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// TestLspciMissing checks that a failing lspci is reported as missing
+// pciutils whether or not sysfs is mounted.
+func TestLspciMissing(t *testing.T) {
+	withSysfs, err := fixtureEnv(filepath.Join("testdata", "fixtures", "rtx4060ti-no-pciutils"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	envs := map[string]probeEnv{
+		"sysfs mounted": withSysfs,
+		"no sysfs":      {fs: fstest.MapFS{}, cmd: fixtureRunner{}},
+	}
+	for name, env := range envs {
+		_, err := linuxProbes{env: env}.detectLspciGPU(context.Background())
+		var w Warning
+		if !errors.As(err, &w) || w.Code != CodeGPULspciMissing {
+			t.Errorf("%s: %v, want %s", name, err, CodeGPULspciMissing)
+		}
+	}
+
+	// A machine without a display controller and without pciutils: the
+	// missing lspci is what the user can fix
+	bridgeOnly := probeEnv{fs: fstest.MapFS{
+		"sys/bus/pci/devices/0000:00:00.0/class":  {Data: []byte("0x060000\n")},
+		"sys/bus/pci/devices/0000:00:00.0/vendor": {Data: []byte("0x8086\n")},
+		"sys/bus/pci/devices/0000:00:00.0/device": {Data: []byte("0xa700\n")},
+	}, cmd: fixtureRunner{}}
+	result := NewRegistry(linuxDetectors(bridgeOnly)...).Run(context.Background(), defaultDetectOptions)
+	if !slices.Contains(result.Specs.Warnings, CodeGPULspciMissing) {
+		t.Errorf("warnings %v, want %s", result.Specs.Warnings, CodeGPULspciMissing)
+	}
+}
@@ -0,0 +1,105 @@
+import Foundation
+
+// PrivacyPolicy controls what leaves the machine, like the Go scanner's
+// privacyPolicy: redactions per Specs field and whether the payload is shown
+// for approval before it is sent.
+struct PrivacyPolicy {
+    var redact: [String: String] = [:]
+    var preview = false
+
+    // Modes each field accepts. os and cpu cannot be omitted: the website
+    // needs them.
+    static let modes: [String: [String]] = [
+        "os": ["full", "family"],
+        "cpu": ["full"],
+        "cpuCores": ["full", "omit"],
+        "cpuSpeedGHz": ["full", "omit"],
+        "gpu": ["full", "omit"],
+        "ramGB": ["full", "bucket", "omit"],
+        "storageGB": ["full", "bucket", "omit"],
+    ]
+
+    // Shorter field names accepted by --redact.
+    static let aliases = ["cores": "cpuCores", "speed": "cpuSpeedGHz", "ram": "ramGB", "storage": "storageGB"]
+
+    // Sizes bucketed values are rounded down to, in GB.
+    static let ramBuckets = [2, 4, 8, 16, 32, 64, 128, 256, 512]
+    static let storageBuckets = [32, 64, 128, 256, 512, 1000, 2000, 4000, 8000, 16000]
+
+    // resolve combines the config file with the --redact and --preview launch
+    // arguments. Argument redactions override the config per field.
+    static func resolve(_ config: Config) throws -> PrivacyPolicy {
+        var policy = PrivacyPolicy()
+        policy.preview = config.preview == true || CommandLine.arguments.contains("--preview")
+        for (field, mode) in (config.redact ?? [:]).sorted(by: { $0.key < $1.key }) {
+            try policy.set(field, mode, source: Scanner.configPath() ?? "config file")
+        }
+        for item in (Scanner.argumentValue("--redact") ?? "").split(separator: ",") {
+            let parts = item.split(separator: "=", maxSplits: 1).map { $0.trimmingCharacters(in: .whitespaces) }
+            guard parts.count == 2 else {
+                throw ScannerError(message: "--redact: \"\(item)\" is not field=mode")
+            }
+            try policy.set(parts[0], parts[1], source: "--redact")
+        }
+        return policy
+    }
+
+    mutating func set(_ field: String, _ mode: String, source: String) throws {
+        let name = PrivacyPolicy.aliases[field] ?? field
+        guard let modes = PrivacyPolicy.modes[name] else {
+            throw ScannerError(message: "\(source): unknown field \"\(field)\"")
+        }
+        guard modes.contains(mode) else {
+            if mode == "omit" {
+                throw ScannerError(message: "\(source): \(name) is required by the website and cannot be omitted (want \(modes.joined(separator: ", ")))")
+            }
+            throw ScannerError(message: "\(source): \(name) cannot be redacted with \"\(mode)\" (want \(modes.joined(separator: ", ")))")
+        }
+        redact[name] = mode
+    }
+
+    // apply returns specs with the policy's redactions.
+    func apply(_ specs: Specs) -> Specs {
+        func mode(_ field: String) -> String {
+            return redact[field] ?? "full"
+        }
+        var ramGB = specs.ramGB
+        switch mode("ramGB") {
+        case "bucket":
+            // Allow for memory reserved by firmware and integrated graphics
+            ramGB = PrivacyPolicy.bucketDown(ramGB + ramGB / 10, PrivacyPolicy.ramBuckets)
+        case "omit":
+            ramGB = 0
+        default:
+            break
+        }
+        var storageGB = specs.storageGB
+        switch mode("storageGB") {
+        case "bucket":
+            storageGB = PrivacyPolicy.bucketDown(storageGB, PrivacyPolicy.storageBuckets)
+        case "omit":
+            storageGB = 0
+        default:
+            break
+        }
+        return Specs(
+            os: mode("os") == "family" ? "macOS" : specs.os,
+            cpu: specs.cpu,
+            cpuCores: mode("cpuCores") == "omit" ? 0 : specs.cpuCores,
+            cpuSpeedGHz: mode("cpuSpeedGHz") == "omit" ? 0 : specs.cpuSpeedGHz,
+            gpu: mode("gpu") == "omit" ? "" : specs.gpu,
+            ramGB: ramGB,
+            storageGB: storageGB
+        )
+    }
+
+    // bucketDown rounds n down to the largest bucket not above it. Values
+    // below the smallest bucket are kept.
+    static func bucketDown(_ n: Int, _ buckets: [Int]) -> Int {
+        var result = n
+        for b in buckets where b <= n {
+            result = b
+        }
+        return result
+    }
+}
@@ -10,7 +10,25 @@ struct Specs: Codable {
     let storageGB: Int
 }
 
+// Config is the optional config file shared with the Go scanner, by default
+// ~/Library/Application Support/doineedanupgrade/config.json.
+struct Config: Decodable {
+    var baseURL: String? = nil
+    // Redact maps Specs fields to a redaction mode, e.g. ["os": "family"].
+    var redact: [String: String]? = nil
+    // Preview asks for approval of the payload before it is sent.
+    var preview: Bool? = nil
+}
+
+struct ScannerError: Error {
+    let message: String
+}
+
 class Scanner {
+    // Site the app talks to when nothing else is configured. `make mac
+    // BASE_URL=...` stores another one in Info.plist under DINAUBaseURL.
+    static let defaultBaseURL = "https://do-i-need-to-upgrade.vercel.app"
+
     static func detectSpecs() -> Specs {
         // OS
         let productName = execCmd("/usr/bin/sw_vers", ["-productName"]).trimmingCharacters(in: .whitespacesAndNewlines)
@@ -88,11 +106,103 @@ class Scanner {
         return "DINAU:" + base64
     }
 
-    static func getURL(_ specs: Specs) -> String {
+    // The app sends the legacy v1 ?specs= value (standard base64 of the Specs
+    // JSON), escaped so "+", "/" and "=" survive the query string.
+    static func getURL(_ specs: Specs, base: String) -> String {
         let encoder = JSONEncoder()
-        guard let jsonData = try? encoder.encode(specs) else { return "https://do-i-need-to-upgrade.vercel.app" }
+        guard let jsonData = try? encoder.encode(specs) else { return base }
         let base64 = jsonData.base64EncodedString()
-        return "https://do-i-need-to-upgrade.vercel.app?specs=" + base64
+            .replacingOccurrences(of: "+", with: "%2B")
+            .replacingOccurrences(of: "/", with: "%2F")
+            .replacingOccurrences(of: "=", with: "%3D")
+        return base + "?specs=" + base64
+    }
+
+    // previewText is the Specs JSON exactly as it will be encoded, for
+    // approval.
+    static func previewText(_ specs: Specs) -> String {
+        let encoder = JSONEncoder()
+        encoder.outputFormatting = .prettyPrinted
+        guard let data = try? encoder.encode(specs), let text = String(data: data, encoding: .utf8) else {
+            return ""
+        }
+        return text
+    }
+
+    // configPath returns the config file location. DINAU_CONFIG overrides it.
+    static func configPath() -> String? {
+        if let path = ProcessInfo.processInfo.environment["DINAU_CONFIG"], !path.isEmpty {
+            return path
+        }
+        guard let dir = FileManager.default.urls(for: .applicationSupportDirectory, in: .userDomainMask).first else {
+            return nil
+        }
+        return dir.appendingPathComponent("doineedanupgrade/config.json").path
+    }
+
+    // loadConfig reads the config file. A missing file is an empty config.
+    static func loadConfig() throws -> Config {
+        guard let path = configPath(), FileManager.default.fileExists(atPath: path) else {
+            return Config()
+        }
+        do {
+            let data = try Data(contentsOf: URL(fileURLWithPath: path))
+            return try JSONDecoder().decode(Config.self, from: data)
+        } catch {
+            throw ScannerError(message: "parse \(path): \(error.localizedDescription)")
+        }
+    }
+
+    // resolveBaseURL picks the site origin like the Go scanner, in order of
+    // precedence: the --base-url argument, the DINAU_BASE_URL environment
+    // variable, the config file and the build-time default.
+    static func resolveBaseURL(_ config: Config) throws -> String {
+        let candidates: [(source: String, value: String?)] = [
+            ("--base-url", argumentValue("--base-url")),
+            ("DINAU_BASE_URL", ProcessInfo.processInfo.environment["DINAU_BASE_URL"]),
+            (configPath() ?? "config file", config.baseURL),
+            ("build default", Bundle.main.object(forInfoDictionaryKey: "DINAUBaseURL") as? String),
+        ]
+        for candidate in candidates {
+            guard let value = candidate.value, !value.isEmpty else { continue }
+            do {
+                return try validateBaseURL(value)
+            } catch let error as ScannerError {
+                throw ScannerError(message: "\(candidate.source): \(error.message)")
+            }
+        }
+        return defaultBaseURL
+    }
+
+    // validateBaseURL checks that raw is an http(s) origin such as
+    // "http://localhost:3000" and returns it without a trailing slash.
+    static func validateBaseURL(_ raw: String) throws -> String {
+        let trimmed = raw.trimmingCharacters(in: .whitespacesAndNewlines)
+        guard let url = URLComponents(string: trimmed),
+              let scheme = url.scheme?.lowercased(), scheme == "http" || scheme == "https",
+              let host = url.host, !host.isEmpty else {
+            throw ScannerError(message: "invalid base URL \"\(raw)\": must be an http or https origin")
+        }
+        if url.user != nil || url.password != nil || !(url.path.isEmpty || url.path == "/") || url.query != nil || url.fragment != nil {
+            throw ScannerError(message: "invalid base URL \"\(raw)\": must be an origin without path, query or credentials")
+        }
+        let hostPart = host.contains(":") && !host.hasPrefix("[") ? "[\(host)]" : host
+        return scheme + "://" + hostPart + (url.port.map { ":\($0)" } ?? "")
+    }
+
+    // argumentValue reads "--name value" or "--name=value" from the launch
+    // arguments, e.g. `open DoINeedAnUpgrade.app --args --base-url ...`.
+    static func argumentValue(_ name: String) -> String? {
+        let args = CommandLine.arguments
+        for (i, arg) in args.enumerated() {
+            if arg == name, i + 1 < args.count {
+                return args[i + 1]
+            }
+            if arg.hasPrefix(name + "=") {
+                return String(arg.dropFirst(name.count + 1))
+            }
+        }
+        return nil
     }
 
     private static func execCmd(_ path: String, _ args: [String]) -> String {
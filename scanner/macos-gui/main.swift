@@ -39,32 +39,78 @@ class AppDelegate: NSObject, NSApplicationDelegate {
         window.makeKeyAndOrderFront(nil)
         NSApp.activate(ignoringOtherApps: true)
 
+        let baseURL: String
+        let privacy: PrivacyPolicy
+        do {
+            let config = try Scanner.loadConfig()
+            baseURL = try Scanner.resolveBaseURL(config)
+            privacy = try PrivacyPolicy.resolve(config)
+        } catch {
+            fail((error as? ScannerError)?.message ?? error.localizedDescription)
+            return
+        }
+
         // Run scan in background
         DispatchQueue.global(qos: .userInitiated).async {
-            let specs = Scanner.detectSpecs()
-            let code = Scanner.encodeSpecs(specs) ?? ""
-            let url = Scanner.getURL(specs)
-
-            // Copy to clipboard
-            let pasteboard = NSPasteboard.general
-            pasteboard.clearContents()
-            pasteboard.setString(code, forType: .string)
+            let specs = privacy.apply(Scanner.detectSpecs())
 
             DispatchQueue.main.async {
-                self.statusLabel.stringValue = "Done! Opening browser..."
-
-                // Open browser
-                if let urlObj = URL(string: url) {
-                    NSWorkspace.shared.open(urlObj)
-                }
-
-                // Close after delay
-                DispatchQueue.main.asyncAfter(deadline: .now() + 1.5) {
-                    NSApp.terminate(nil)
+                if privacy.preview && !self.confirmSend(specs, baseURL: baseURL) {
+                    self.statusLabel.stringValue = "Nothing was sent."
+                    DispatchQueue.main.asyncAfter(deadline: .now() + 1.5) {
+                        NSApp.terminate(nil)
+                    }
+                    return
                 }
+                self.send(specs, baseURL: baseURL)
             }
         }
     }
+
+    // send copies the code to the clipboard and opens the site.
+    func send(_ specs: Specs, baseURL: String) {
+        let code = Scanner.encodeSpecs(specs) ?? ""
+        let url = Scanner.getURL(specs, base: baseURL)
+
+        // Copy to clipboard
+        let pasteboard = NSPasteboard.general
+        pasteboard.clearContents()
+        pasteboard.setString(code, forType: .string)
+
+        statusLabel.stringValue = "Done! Opening browser..."
+
+        // Open browser
+        if let urlObj = URL(string: url) {
+            NSWorkspace.shared.open(urlObj)
+        }
+
+        // Close after delay
+        DispatchQueue.main.asyncAfter(deadline: .now() + 1.5) {
+            NSApp.terminate(nil)
+        }
+    }
+
+    // confirmSend shows the payload and reports whether the user approved
+    // sending it.
+    func confirmSend(_ specs: Specs, baseURL: String) -> Bool {
+        let alert = NSAlert()
+        alert.messageText = "Send these specs?"
+        alert.informativeText = "The following will be copied to the clipboard and sent to \(baseURL):\n\n" + Scanner.previewText(specs)
+        alert.addButton(withTitle: "Send")
+        alert.addButton(withTitle: "Cancel")
+        NSApp.activate(ignoringOtherApps: true)
+        return alert.runModal() == .alertFirstButtonReturn
+    }
+
+    // fail shows an error and quits without sending anything.
+    func fail(_ message: String) {
+        let alert = NSAlert()
+        alert.alertStyle = .critical
+        alert.messageText = "DoINeedAnUpgrade"
+        alert.informativeText = message
+        alert.runModal()
+        NSApp.terminate(nil)
+    }
 }
 
 // Main entry point
@@ -2,7 +2,7 @@ package main
 
 import (
 	"bufio"
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -12,7 +12,9 @@ import (
 	"strings"
 )
 
-const baseURL = "https://do-i-need-to-upgrade.vercel.app"
+// version is the scanner version, set at build time with
+// -ldflags "-X main.version=...".
+var version = "dev"
 
 type Specs struct {
 	OS          string  `json:"os"`
@@ -22,11 +24,27 @@ type Specs struct {
 	GPU         string  `json:"gpu"`
 	RAMGB       int     `json:"ramGB"`
 	StorageGB   int     `json:"storageGB"`
+	// Warnings holds the codes of detection warnings so they survive encoding.
+	Warnings []string `json:"warnings,omitempty"`
+	// GuessedFields and RAMApproximate mirror the website's UserSpecs
+	// properties and are derived from DetectionResult.Provenance.
+	GuessedFields  []string `json:"guessedFields,omitempty"`
+	RAMApproximate bool     `json:"ramApproximate,omitempty"`
 }
 
 type DetectionResult struct {
-	Specs  Specs
-	Errors []string
+	Specs      Specs                    `json:"specs"`
+	Warnings   []Warning                `json:"warnings,omitempty"`
+	Provenance map[Component]Provenance `json:"provenance,omitempty"`
+	// GPUs lists every adapter found, where the platform detector enumerates
+	// them.
+	GPUs []GPU `json:"gpus,omitempty"`
+	// VRAM is the primary GPU's video memory, where it could be read.
+	VRAM *VRAM `json:"vram,omitempty"`
+
+	// live marks a detection of this machine, as opposed to a replayed
+	// snapshot or specs typed in by hand. Only live results are signed.
+	live bool
 }
 
 // cleanCPUName normalises CPU brand strings for matching.
@@ -44,12 +62,83 @@ func cleanCPUName(name string) string {
 }
 
 func main() {
-	// Use GUI mode by default (terminal mode available via --terminal flag)
-	if len(os.Args) > 1 && os.Args[1] == "--terminal" {
-		runTerminal()
-	} else {
-		runGUI()
+	args, baseURLFlag := extractFlag(os.Args[1:], "--base-url")
+	args, redactFlag := extractFlag(args, "--redact")
+	args, previewFlag := extractBoolFlag(args, "--preview")
+	args, signKeyFlag := extractFlag(args, "--sign-key")
+	args, noHistoryFlag := extractBoolFlag(args, "--no-history")
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring config file: %v\n", err)
+	}
+	if baseURL, err = resolveBaseURL(baseURLFlag, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	if privacy, err = resolvePrivacy(redactFlag, previewFlag, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	if signer, err = resolveSigner(signKeyFlag); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	historyEnabled = !cfg.NoHistory && !noHistoryFlag
+	if defaultDetectOptions.GPU == "" {
+		defaultDetectOptions.GPU = cfg.GPU
+	}
+	os.Exit(runCLI(args))
+}
+
+// extractFlag removes "--name value" or "--name=value" from args and returns
+// the remaining args and the value.
+func extractFlag(args []string, name string) ([]string, string) {
+	var rest []string
+	value := ""
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == name && i+1 < len(args):
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], name+"="):
+			value = strings.TrimPrefix(args[i], name+"=")
+		default:
+			rest = append(rest, args[i])
+		}
 	}
+	return rest, value
+}
+
+// extractBoolFlag removes "--name" from args and reports whether it was there.
+func extractBoolFlag(args []string, name string) ([]string, bool) {
+	var rest []string
+	found := false
+	for _, arg := range args {
+		if arg == name {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// runReplay runs the Linux detectors against a snapshot archive or fixture
+// directory and prints the resulting Specs as JSON, for comparison with the
+// golden file.
+func runReplay(src string) int {
+	env, err := snapshotEnv(src)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	result := NewRegistry(linuxDetectors(env)...).Run(context.Background(), defaultDetectOptions)
+	for _, e := range warningMessages(result.Warnings) {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", e)
+	}
+	out, _ := json.MarshalIndent(result.Specs, "", "  ")
+	fmt.Println(string(out))
+	return exitOK
 }
 
 func runTerminal() {
@@ -57,23 +146,23 @@ func runTerminal() {
 	fmt.Println("=== DoINeedAnUpgrade Hardware Scanner ===")
 	fmt.Println()
 
-	result := detectSpecs()
+	result, previous := detectAndRecord()
 
-	fmt.Printf("OS:      %s\n", result.Specs.OS)
-	fmt.Printf("CPU:     %s (%d cores @ %.1f GHz)\n", result.Specs.CPU, result.Specs.CPUCores, result.Specs.CPUSpeedGHz)
-	fmt.Printf("GPU:     %s\n", result.Specs.GPU)
-	fmt.Printf("RAM:     %d GB\n", result.Specs.RAMGB)
-	fmt.Printf("Storage: %d GB free\n", result.Specs.StorageGB)
+	printResult(os.Stdout, result)
+	if previous != nil {
+		fmt.Println()
+		printChanges(os.Stdout, previous, result.Specs)
+	}
 
-	if len(result.Errors) > 0 {
+	if !confirmSend(result, askInTerminal) {
 		fmt.Println()
-		fmt.Println("Warnings:")
-		for _, e := range result.Errors {
-			fmt.Printf("  - %s\n", e)
-		}
+		fmt.Println("Cancelled, nothing was copied or sent.")
+		fmt.Println()
+		waitForEnter()
+		return
 	}
 
-	code := encodeSpecs(result.Specs)
+	code := encodeResult(result)
 
 	fmt.Println()
 	fmt.Println("Your hardware specs:")
@@ -84,35 +173,30 @@ func runTerminal() {
 	copyToClipboard(code)
 
 	// Open browser
-	url := getURL(result.Specs)
+	url := shareURL(result)
 	fmt.Println()
 	fmt.Println("Opening browser...")
 	openBrowser(url)
 
 	fmt.Println()
 	fmt.Println("Done! Your browser should open with your specs imported.")
-	fmt.Println("If it doesn't, you can paste the code manually on the website.")
+	fmt.Printf("If it doesn't, you can paste the code manually on %s\n", baseURL)
 	fmt.Println()
 
 	waitForEnter()
 }
 
-func encodeSpecs(specs Specs) string {
-	jsonData, err := json.Marshal(specs)
-	if err != nil {
-		return ""
+// getURL builds the site link with the specs in the query string. Over-long
+// payloads are still returned; the site may cut them off.
+func getURL(result DetectionResult) string {
+	param, err := encodeSpecsParam(newPayload(result), baseURL, urlBudget)
+	if param == "" {
+		return baseURL
 	}
-	encoded := base64.StdEncoding.EncodeToString(jsonData)
-	return "DINAU:" + encoded
-}
-
-func getURL(specs Specs) string {
-	jsonData, err := json.Marshal(specs)
 	if err != nil {
-		return baseURL
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
 	}
-	encoded := base64.StdEncoding.EncodeToString(jsonData)
-	return baseURL + "?specs=" + encoded
+	return baseURL + "?specs=" + param
 }
 
 func waitForEnter() {
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"time"
+)
+
+// payloadPrefix marks a DINAU code, e.g. "DINAU:eyJvcyI6...".
+const payloadPrefix = "DINAU:"
+
+// payloadVersion is the schema version written by this scanner.
+//
+// Compatibility rule: the core Specs fields (os, cpu, cpuCores, cpuSpeedGHz,
+// gpu, ramGB, storageGB) always stay at the top level of the JSON with their
+// v1 types, so website builds that only understand v1 keep reading them. New
+// data goes into a named section under "ext", which decoders skip when they
+// don't know it. The version is only bumped when the meaning of a top-level
+// field changes.
+const payloadVersion = 2
+
+// Ext section names. extSignature is in sign.go, extGPUs and extVRAM in gpu.go.
+const (
+	extProvenance = "provenance"
+	extWarnings   = "warnings"
+)
+
+// Payload is the decoded content of a DINAU code. Version 1 payloads are the
+// bare Specs JSON; they decode with Version 1 and no checksum.
+type Payload struct {
+	Specs
+	Version  int    `json:"v,omitempty"`
+	Scanner  string `json:"scanner,omitempty"`
+	Checksum string `json:"sum,omitempty"`
+	// Ext holds optional extended sections keyed by name.
+	Ext map[string]json.RawMessage `json:"ext,omitempty"`
+
+	// fromScan marks payloads built from a live detection of this machine,
+	// which marshal signs when the build has a signing key.
+	fromScan bool
+}
+
+var (
+	ErrMissingPrefix    = errors.New("code does not start with " + payloadPrefix)
+	ErrChecksumMismatch = errors.New("payload checksum does not match its content")
+)
+
+// newPayload wraps a detection result in a v2 envelope, including the
+// provenance, full warnings, the primary GPU's VRAM and, on machines with
+// several, the GPU list as extended sections. The privacy policy's
+// redactions are applied first, so every code and link built from a
+// detection is redacted.
+func newPayload(result DetectionResult) Payload {
+	result = privacy.apply(result)
+	p := Payload{Specs: result.Specs, fromScan: result.live}
+	if len(result.Provenance) > 0 {
+		p.setExt(extProvenance, result.Provenance)
+	}
+	if len(result.Warnings) > 0 {
+		p.setExt(extWarnings, result.Warnings)
+	}
+	if len(result.GPUs) > 1 {
+		p.setExt(extGPUs, result.GPUs)
+	}
+	if result.VRAM != nil {
+		p.setExt(extVRAM, result.VRAM)
+	}
+	return p
+}
+
+// setExt stores v as the named extended section.
+func (p *Payload) setExt(name string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if p.Ext == nil {
+		p.Ext = make(map[string]json.RawMessage)
+	}
+	p.Ext[name] = data
+}
+
+// ext decodes the named extended section into v and reports whether it was
+// present.
+func (p Payload) ext(name string, v any) (bool, error) {
+	data, ok := p.Ext[name]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// checksum is the CRC-32 of the payload JSON with the sum field left out.
+func (p Payload) checksum() (string, error) {
+	p.Checksum = ""
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE(data)), nil
+}
+
+// marshal stamps the version, scanner version, signature and checksum and
+// returns the payload JSON.
+func (p Payload) marshal() ([]byte, error) {
+	p.Version = payloadVersion
+	p.Scanner = version
+	if p.fromScan && signer != nil {
+		if err := p.sign(signer, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	sum, err := p.checksum()
+	if err != nil {
+		return nil, err
+	}
+	p.Checksum = sum
+	return json.Marshal(p)
+}
+
+// encodePayload produces the DINAU code for a payload.
+func encodePayload(p Payload) string {
+	jsonData, err := p.marshal()
+	if err != nil {
+		return ""
+	}
+	return payloadPrefix + base64.StdEncoding.EncodeToString(jsonData)
+}
+
+func encodeResult(result DetectionResult) string {
+	return encodePayload(newPayload(result))
+}
+
+func encodeSpecs(specs Specs) string {
+	return encodePayload(Payload{Specs: specs})
+}
+
+// decodePayload parses a DINAU code of any supported version.
+func decodePayload(code string) (Payload, error) {
+	code = strings.TrimSpace(code)
+	if !strings.HasPrefix(code, payloadPrefix) {
+		return Payload{}, ErrMissingPrefix
+	}
+	jsonData, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(code, payloadPrefix))
+	if err != nil {
+		return Payload{}, fmt.Errorf("invalid base64: %w", err)
+	}
+	return parsePayloadJSON(jsonData)
+}
+
+// parsePayloadJSON parses and validates payload JSON. Payloads without a
+// version are v1.
+func parsePayloadJSON(jsonData []byte) (Payload, error) {
+	if err := validatePayloadJSON(jsonData); err != nil {
+		return Payload{}, err
+	}
+	var p Payload
+	if err := json.Unmarshal(jsonData, &p); err != nil {
+		return Payload{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	switch {
+	case p.Version == 0:
+		p.Version = 1
+	case p.Version > payloadVersion:
+		// Newer payloads keep the core fields readable, see payloadVersion
+	case p.Version == payloadVersion:
+		sum, err := p.checksum()
+		if err != nil {
+			return Payload{}, err
+		}
+		if p.Checksum != sum {
+			return Payload{}, ErrChecksumMismatch
+		}
+	default:
+		return Payload{}, fmt.Errorf("unsupported payload version %d", p.Version)
+	}
+	return p, nil
+}
+
+// FieldTypeError reports a payload field whose JSON type is wrong.
+type FieldTypeError struct {
+	Field string
+	Want  string
+	Got   string
+}
+
+func (e *FieldTypeError) Error() string {
+	if e.Got == "" {
+		return fmt.Sprintf("field %q is missing (want %s)", e.Field, e.Want)
+	}
+	return fmt.Sprintf("field %q is %s, want %s", e.Field, e.Got, e.Want)
+}
+
+// payloadFieldTypes lists the JSON type each known field must have. It
+// follows the website's decodeSpecsPayload: os and cpu are required strings,
+// gpu may be null, and the numbers may be null or left out. Unknown fields
+// are ignored.
+var payloadFieldTypes = []struct {
+	name     string
+	want     string
+	required bool
+}{
+	{"os", "string", true},
+	{"cpu", "string", true},
+	{"gpu", "string", false},
+	{"cpuCores", "integer", false},
+	{"cpuSpeedGHz", "number", false},
+	{"ramGB", "integer", false},
+	{"storageGB", "integer", false},
+	{"ramApproximate", "boolean", false},
+	{"guessedFields", "array of strings", false},
+	{"warnings", "array of strings", false},
+	{"v", "integer", false},
+	{"scanner", "string", false},
+	{"sum", "string", false},
+	{"ext", "object", false},
+}
+
+// validatePayloadJSON checks the structure of payload JSON so a bad code is
+// reported by field rather than as a generic unmarshal failure.
+func validatePayloadJSON(jsonData []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(jsonData, &fields); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("invalid JSON: payload is %s, want object", typeErr.Value)
+		}
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if fields == nil {
+		return errors.New("invalid JSON: payload is null, want object")
+	}
+	for _, f := range payloadFieldTypes {
+		raw, ok := fields[f.name]
+		if !ok {
+			if f.required {
+				return &FieldTypeError{Field: f.name, Want: f.want}
+			}
+			continue
+		}
+		got := jsonKind(raw)
+		if got == "null" && !f.required {
+			continue
+		}
+		if !kindMatches(raw, got, f.want) {
+			return &FieldTypeError{Field: f.name, Want: f.want, Got: got}
+		}
+	}
+	return nil
+}
+
+// jsonKind names the JSON type of a raw value.
+func jsonKind(raw json.RawMessage) string {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" {
+		return "empty"
+	}
+	switch trimmed[0] {
+	case '"':
+		return "string"
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+	return "number"
+}
+
+func kindMatches(raw json.RawMessage, got, want string) bool {
+	switch want {
+	case "integer":
+		var n int64
+		return got == "number" && json.Unmarshal(raw, &n) == nil
+	case "array of strings":
+		var list []string
+		return got == "array" && json.Unmarshal(raw, &list) == nil
+	}
+	return got == want
+}
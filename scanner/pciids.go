@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// pciIDsData is a table of display adapters in pci.ids format (vendors and
+// their devices, without subsystems), gzipped. The checked-in copy is a
+// hand-picked subset; `make pciids` regenerates the full display adapter part
+// of a pci.ids. Devices it does not name are left to lspci.
+//
+//go:embed pciids.gz
+var pciIDsData []byte
+
+// pciIDTable names PCI vendors and devices by their lowercase hex IDs.
+type pciIDTable struct {
+	vendors map[string]string // "10de"
+	devices map[string]string // "10de:2560"
+}
+
+// pciIDs is parsed on first use; only the Linux sysfs detector needs it.
+var pciIDs = sync.OnceValue(func() pciIDTable {
+	t := pciIDTable{vendors: make(map[string]string), devices: make(map[string]string)}
+	gz, err := gzip.NewReader(bytes.NewReader(pciIDsData))
+	if err != nil {
+		return t
+	}
+	defer gz.Close()
+	vendor := ""
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "\t\t") {
+			continue
+		}
+		id, name, ok := strings.Cut(strings.TrimPrefix(line, "\t"), "  ")
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			if vendor != "" {
+				t.devices[vendor+":"+strings.ToLower(id)] = name
+			}
+			continue
+		}
+		vendor = strings.ToLower(id)
+		t.vendors[vendor] = name
+	}
+	return t
+})
+
+// describe names a device the way lspci does, so normalizeGPUName sees the
+// same text with or without pciutils: "NVIDIA Corporation GA106M [GeForce
+// RTX 3060 Mobile / Max-Q]". A device missing from the table gets lspci's
+// placeholder, "Intel Corporation Device 7d67" or "Device 1234:1111" for an
+// unknown vendor, and ok is false.
+func (t pciIDTable) describe(vendor, device string) (description string, ok bool) {
+	vendor, device = strings.ToLower(vendor), strings.ToLower(device)
+	vendorName, ok := t.vendors[vendor]
+	if !ok {
+		return "Device " + vendor + ":" + device, false
+	}
+	if name, ok := t.devices[vendor+":"+device]; ok {
+		return vendorName + " " + name, true
+	}
+	return vendorName + " Device " + device, false
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Redaction modes for a Specs field.
+const (
+	redactFull   = "full"   // send the detected value
+	redactFamily = "family" // os only: "Windows", "macOS" or "Linux"
+	redactBucket = "bucket" // ramGB and storageGB: round down to a common size
+	redactOmit   = "omit"   // send an empty value; the website asks for it
+)
+
+// redactModes lists the modes each field accepts. os and cpu cannot be
+// omitted: the website's /api/import rejects a payload without them, and the
+// scanner would then send a ?specs= link instead of the upload the user saw
+// in the preview.
+var redactModes = map[Component][]string{
+	ComponentOS:       {redactFull, redactFamily},
+	ComponentCPU:      {redactFull},
+	ComponentCPUCores: {redactFull, redactOmit},
+	ComponentCPUSpeed: {redactFull, redactOmit},
+	ComponentGPU:      {redactFull, redactOmit},
+	ComponentRAM:      {redactFull, redactBucket, redactOmit},
+	ComponentStorage:  {redactFull, redactBucket, redactOmit},
+}
+
+// redactAliases are the shorter field names accepted by --redact.
+var redactAliases = map[string]Component{
+	"cores":   ComponentCPUCores,
+	"speed":   ComponentCPUSpeed,
+	"ram":     ComponentRAM,
+	"storage": ComponentStorage,
+}
+
+// ramBuckets and storageBuckets are the sizes bucketed values are rounded
+// down to, in GB.
+var (
+	ramBuckets     = []int{2, 4, 8, 16, 32, 64, 128, 256, 512}
+	storageBuckets = []int{32, 64, 128, 256, 512, 1000, 2000, 4000, 8000, 16000}
+)
+
+// privacyPolicy controls what leaves the machine. Redactions apply to every
+// payload the scanner builds from a detection; Preview makes the send paths
+// show the payload and wait for approval.
+type privacyPolicy struct {
+	Redact  map[Component]string
+	Preview bool
+}
+
+// privacy is the policy in use, resolved once at startup.
+var privacy privacyPolicy
+
+// resolvePrivacy combines the config file with the --redact and --preview
+// flags. Flag redactions override the config per field.
+func resolvePrivacy(redactFlag string, previewFlag bool, cfg Config) (privacyPolicy, error) {
+	policy := privacyPolicy{Redact: make(map[Component]string), Preview: previewFlag || cfg.Preview}
+	fields := make([]string, 0, len(cfg.Redact))
+	for field := range cfg.Redact {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if err := policy.set(field, cfg.Redact[field]); err != nil {
+			return policy, fmt.Errorf("%s: %w", configPath(), err)
+		}
+	}
+	for _, item := range strings.Split(redactFlag, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		field, mode, ok := strings.Cut(item, "=")
+		if !ok {
+			return policy, fmt.Errorf("--redact: %q is not field=mode", item)
+		}
+		if err := policy.set(field, mode); err != nil {
+			return policy, fmt.Errorf("--redact: %w", err)
+		}
+	}
+	return policy, nil
+}
+
+func (p privacyPolicy) set(field, mode string) error {
+	c, ok := redactAliases[field]
+	if !ok {
+		c = Component(field)
+	}
+	modes, ok := redactModes[c]
+	if !ok {
+		return fmt.Errorf("unknown field %q", field)
+	}
+	for _, m := range modes {
+		if m == mode {
+			p.Redact[c] = mode
+			return nil
+		}
+	}
+	if mode == redactOmit {
+		return fmt.Errorf("%s is required by the website and cannot be omitted (want %s)", c, strings.Join(modes, ", "))
+	}
+	return fmt.Errorf("%s cannot be redacted with %q (want %s)", c, mode, strings.Join(modes, ", "))
+}
+
+// apply returns result with the policy's redactions. Provenance and warnings
+// of redacted fields are dropped too, since they can repeat the value.
+func (p privacyPolicy) apply(result DetectionResult) DetectionResult {
+	if len(p.Redact) == 0 {
+		return result
+	}
+	specs := result.Specs
+	redacted := make(map[Component]bool)
+	for c, mode := range p.Redact {
+		if mode == redactFull {
+			continue
+		}
+		redacted[c] = true
+		switch c {
+		case ComponentOS:
+			specs.OS = osFamily(specs.OS)
+		case ComponentCPUCores:
+			specs.CPUCores = 0
+		case ComponentCPUSpeed:
+			specs.CPUSpeedGHz = 0
+		case ComponentGPU:
+			specs.GPU = ""
+		case ComponentRAM:
+			if mode == redactBucket {
+				// Allow for memory reserved by firmware and integrated graphics
+				specs.RAMGB = bucketDown(specs.RAMGB+specs.RAMGB/10, ramBuckets)
+			} else {
+				specs.RAMGB = 0
+			}
+		case ComponentStorage:
+			if mode == redactBucket {
+				specs.StorageGB = bucketDown(specs.StorageGB, storageBuckets)
+			} else {
+				specs.StorageGB = 0
+			}
+		}
+	}
+	if len(redacted) == 0 {
+		return result
+	}
+
+	provenance := make(map[Component]Provenance)
+	for c, prov := range result.Provenance {
+		if !redacted[c] {
+			provenance[c] = prov
+		}
+	}
+	var warnings []Warning
+	for _, w := range result.Warnings {
+		if !redacted[w.Field] {
+			warnings = append(warnings, w)
+		}
+	}
+	gpus, vram := result.GPUs, result.VRAM
+	if redacted[ComponentGPU] {
+		gpus, vram = nil, nil
+	}
+	specs.Warnings = warningCodes(warnings)
+	return DetectionResult{Specs: specs, Warnings: warnings, Provenance: provenance, GPUs: gpus, VRAM: vram, live: result.live}
+}
+
+// osFamily reduces an OS name to its platform, using the same keywords as the
+// website's compareSpecs.
+func osFamily(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case lower == "":
+		return ""
+	case strings.Contains(lower, "windows"):
+		return "Windows"
+	case strings.Contains(lower, "macos") || strings.Contains(lower, "mac os") || strings.Contains(lower, "os x"):
+		return "macOS"
+	}
+	return "Linux"
+}
+
+// bucketDown rounds n down to the largest bucket not above it. Values below
+// the smallest bucket are kept, so tiny machines are not reported as larger.
+func bucketDown(n int, buckets []int) int {
+	result := n
+	for _, b := range buckets {
+		if b <= n {
+			result = b
+		}
+	}
+	return result
+}
+
+// previewText is the payload exactly as it will be encoded, for approval.
+func previewText(result DetectionResult) string {
+	data, err := newPayload(result).marshal()
+	if err != nil {
+		return err.Error()
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return indented.String()
+}
+
+// confirmSend shows the payload with ask when the policy requires a preview
+// and reports whether the user approved sending it.
+func confirmSend(result DetectionResult, ask func(preview string) bool) bool {
+	if !privacy.Preview {
+		return true
+	}
+	return ask(previewText(result))
+}
+
+// askInTerminal prints the preview and waits for a yes on stdin.
+func askInTerminal(preview string) bool {
+	fmt.Println()
+	fmt.Printf("The following will be copied to the clipboard and sent to %s:\n\n", baseURL)
+	fmt.Println(preview)
+	fmt.Println()
+	fmt.Print("Send these specs? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolvePrivacyRequiredFields(t *testing.T) {
+	for _, redact := range []string{"os=omit", "cpu=omit", "gpu=omit,cpu=omit"} {
+		if _, err := resolvePrivacy(redact, false, Config{}); err == nil || !strings.Contains(err.Error(), "required") {
+			t.Errorf("--redact %s: err = %v, want os and cpu to be required", redact, err)
+		}
+	}
+	if _, err := resolvePrivacy("", false, Config{Redact: map[string]string{"os": "omit"}}); err == nil {
+		t.Error(`config {"os": "omit"} accepted`)
+	}
+	if _, err := resolvePrivacy("cores=omit,speed=omit,gpu=omit,ram=omit,storage=omit", false, Config{}); err != nil {
+		t.Errorf("optional fields: %v", err)
+	}
+}
+
+// TestRedactKeepsImportable checks the strongest redaction of every field
+// still sends the os and cpu the website's /api/import requires.
+func TestRedactKeepsImportable(t *testing.T) {
+	policy := privacyPolicy{Redact: make(map[Component]string)}
+	for c, modes := range redactModes {
+		policy.Redact[c] = modes[len(modes)-1]
+	}
+	result := DetectionResult{Specs: Specs{OS: "Windows 11 Pro", CPU: "Intel Core i5-12400F", CPUCores: 6, GPU: "NVIDIA GeForce RTX 3060", RAMGB: 16, StorageGB: 300}}
+	specs := policy.apply(result).Specs
+	if strings.TrimSpace(specs.OS) == "" || strings.TrimSpace(specs.CPU) == "" {
+		t.Errorf("redacted to %+v, want os and cpu kept", specs)
+	}
+	if specs.OS != "Windows" || specs.GPU != "" || specs.RAMGB != 0 {
+		t.Errorf("redacted to %+v, want os family and gpu and ram omitted", specs)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+// Source says where a detected value came from.
+type Source string
+
+const (
+	SourceProcfs         Source = "procfs"
+	SourceSysfs          Source = "sysfs"
+	SourceFile           Source = "file" // other files, e.g. /etc/os-release
+	SourceCommand        Source = "command"
+	SourceLspci          Source = "lspci"
+	SourceSysctl         Source = "sysctl"
+	SourceSystemProfiler Source = "system_profiler"
+	SourceWMI            Source = "wmi"
+	SourceTable          Source = "table"
+	SourceExtrapolation  Source = "extrapolation"
+	SourceFallback       Source = "fallback"
+	SourceUnknown        Source = "unknown"
+)
+
+// Confidence says how far a detected value can be trusted.
+type Confidence string
+
+const (
+	// ConfidenceExact values were read directly from the system.
+	ConfidenceExact Confidence = "exact"
+	// ConfidenceHigh values were derived from exact data but rounded or
+	// otherwise approximated, e.g. RAM from MemTotal.
+	ConfidenceHigh Confidence = "high"
+	// ConfidenceEstimated values are guesses the user should double-check,
+	// e.g. logical threads standing in for cores.
+	ConfidenceEstimated Confidence = "estimated"
+)
+
+// Provenance records how one Specs field was detected.
+type Provenance struct {
+	Detector   string     `json:"detector"`
+	Source     Source     `json:"source"`
+	Confidence Confidence `json:"confidence"`
+}
+
+// siteFieldLabels maps components to the field labels the website uses in
+// UserSpecs.guessedFields.
+var siteFieldLabels = map[Component]string{
+	ComponentOS:       "OS",
+	ComponentCPU:      "CPU",
+	ComponentCPUCores: "CPU",
+	ComponentCPUSpeed: "CPU",
+	ComponentGPU:      "GPU",
+	ComponentRAM:      "RAM",
+	ComponentStorage:  "Storage",
+}
+
+// applyProvenance fills the website's guessedFields and ramApproximate
+// properties from per-field provenance.
+func applyProvenance(specs *Specs, provenance map[Component]Provenance) {
+	specs.GuessedFields = nil
+	seen := make(map[string]bool)
+	for _, c := range components {
+		p, ok := provenance[c]
+		if !ok || p.Confidence != ConfidenceEstimated {
+			continue
+		}
+		label := siteFieldLabels[c]
+		if !seen[label] {
+			seen[label] = true
+			specs.GuessedFields = append(specs.GuessedFields, label)
+		}
+	}
+	ram, ok := provenance[ComponentRAM]
+	specs.RAMApproximate = ok && ram.Confidence == ConfidenceEstimated
+}
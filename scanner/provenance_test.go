@@ -0,0 +1,13 @@
+package main
+
+import "testing"
+
+func TestApplyProvenanceRAMApproximate(t *testing.T) {
+	for _, confidence := range []Confidence{ConfidenceExact, ConfidenceHigh, ConfidenceEstimated} {
+		var specs Specs
+		applyProvenance(&specs, map[Component]Provenance{ComponentRAM: {Detector: "meminfo", Confidence: confidence}})
+		if want := confidence == ConfidenceEstimated; specs.RAMApproximate != want {
+			t.Errorf("%s RAM: ramApproximate = %t, want %t", confidence, specs.RAMApproximate, want)
+		}
+	}
+}
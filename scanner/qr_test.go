@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The tests decode the encoder's output with an independent reader built from
+// ISO/IEC 18004: format and version information from their tables, the
+// function pattern layout, unmasking, the zigzag placement, de-interleaving,
+// a Reed-Solomon syndrome check and the byte mode segment.
+
+// qrFormatTable is the format information for levels L and M with masks 0-7
+// (ISO/IEC 18004 table C.1).
+var qrFormatTable = map[int]struct {
+	level qrLevel
+	mask  int
+}{
+	0b111011111000100: {qrLevelL, 0}, 0b111001011110011: {qrLevelL, 1},
+	0b111110110101010: {qrLevelL, 2}, 0b111100010011101: {qrLevelL, 3},
+	0b110011000101111: {qrLevelL, 4}, 0b110001100011000: {qrLevelL, 5},
+	0b110110001000001: {qrLevelL, 6}, 0b110100101110110: {qrLevelL, 7},
+	0b101010000010010: {qrLevelM, 0}, 0b101000100100101: {qrLevelM, 1},
+	0b101111001111100: {qrLevelM, 2}, 0b101101101001011: {qrLevelM, 3},
+	0b100010111111001: {qrLevelM, 4}, 0b100000011001110: {qrLevelM, 5},
+	0b100111110010111: {qrLevelM, 6}, 0b100101010100000: {qrLevelM, 7},
+}
+
+// qrVersionTable is the version information of some versions (table D.1).
+var qrVersionTable = map[int]int{7: 0x07C94, 8: 0x085BC, 10: 0x0A4D3, 21: 0x15683, 40: 0x28C69}
+
+// qrAlignmentTable is the alignment pattern row/column list (table E.1).
+var qrAlignmentTable = map[int][]int{
+	1: nil, 2: {6, 18}, 6: {6, 34}, 7: {6, 22, 38}, 10: {6, 28, 50},
+	15: {6, 26, 48, 70}, 21: {6, 28, 50, 72, 94}, 32: {6, 34, 60, 86, 112, 138},
+	36: {6, 24, 50, 76, 102, 128, 154}, 40: {6, 30, 58, 86, 114, 142, 170},
+}
+
+func TestQRAlignmentPositions(t *testing.T) {
+	for version, want := range qrAlignmentTable {
+		if got := qrAlignmentPositions(version); !reflect.DeepEqual(got, want) {
+			t.Errorf("version %d: alignment at %v, want %v", version, got, want)
+		}
+	}
+}
+
+// TestQRCapacity checks version and level selection against the byte mode
+// capacities of table 7: level M is used up to version 40, then level L.
+func TestQRCapacity(t *testing.T) {
+	tests := []struct {
+		n       int
+		version int
+		level   qrLevel
+	}{
+		{14, 1, qrLevelM}, {15, 2, qrLevelM}, {26, 2, qrLevelM}, {27, 3, qrLevelM},
+		{84, 5, qrLevelM}, {85, 6, qrLevelM}, {213, 10, qrLevelM}, {214, 11, qrLevelM},
+		{2331, 40, qrLevelM}, {2332, 36, qrLevelL}, {2953, 40, qrLevelL},
+	}
+	for _, tt := range tests {
+		data := bytes.Repeat([]byte("a"), tt.n)
+		q, err := encodeQR(data)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", tt.n, err)
+		}
+		got, version, level, err := decodeQRMatrix(q.modules)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", tt.n, err)
+		}
+		if version != tt.version || level != tt.level {
+			t.Errorf("%d bytes: version %d level %d, want version %d level %d", tt.n, version, level, tt.version, tt.level)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%d bytes decoded to %d bytes", tt.n, len(got))
+		}
+	}
+	if _, err := encodeQR(make([]byte, 2954)); !errors.Is(err, ErrQRTooLong) {
+		t.Errorf("2954 bytes: err = %v, want %v", err, ErrQRTooLong)
+	}
+}
+
+func TestQRRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"https://do-i-need-to-upgrade.vercel.app?import=3f9a2c",
+		"https://do-i-need-to-upgrade.vercel.app?specs=z1." + strings.Repeat("Ab3-_x", 40),
+		"bytes \x00\xff\xec\x11 and UTF-8 ✓",
+		strings.Repeat("0123456789", 150),
+	}
+	for _, in := range inputs {
+		q, err := encodeQR([]byte(in))
+		if err != nil {
+			t.Fatalf("%.20q: %v", in, err)
+		}
+		got, _, _, err := decodeQRMatrix(q.modules)
+		if err != nil {
+			t.Errorf("%.20q: %v", in, err)
+		} else if string(got) != in {
+			t.Errorf("%.20q decoded to %.20q", in, got)
+		}
+	}
+}
+
+// TestQROutput reads the terminal and PNG renderings back into modules.
+func TestQROutput(t *testing.T) {
+	link := "https://do-i-need-to-upgrade.vercel.app?import=3f9a2c"
+	q, err := encodeQR([]byte(link))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, invert := range []bool{false, true} {
+		m := terminalModules(t, q.terminal(invert), invert)
+		if !reflect.DeepEqual(m, q.modules) {
+			t.Errorf("terminal(%t) does not show the symbol", invert)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "qr.png")
+	if err := writeQRPNG(path, link); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const scale = 8
+	if got, want := img.Bounds().Dx(), (q.size+2*qrQuietZone)*scale; got != want {
+		t.Fatalf("PNG is %d pixels wide, want %d", got, want)
+	}
+	m := make([][]bool, q.size)
+	for y := range m {
+		m[y] = make([]bool, q.size)
+		for x := range m[y] {
+			r, _, _, _ := img.At((x+qrQuietZone)*scale+scale/2, (y+qrQuietZone)*scale+scale/2).RGBA()
+			m[y][x] = r < 0x8000
+		}
+	}
+	if got, _, _, err := decodeQRMatrix(m); err != nil || string(got) != link {
+		t.Errorf("PNG decodes to %q, %v", got, err)
+	}
+}
+
+// terminalModules turns half-block output back into the symbol's modules,
+// dropping the quiet zone.
+func terminalModules(t *testing.T, out string, invert bool) [][]bool {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	n := len([]rune(lines[0]))
+	rows := make([][]bool, 0, 2*len(lines))
+	for _, line := range lines {
+		top, bottom := make([]bool, 0, n), make([]bool, 0, n)
+		for _, r := range line {
+			// Drawn halves are the light modules unless inverted
+			upper, lower := r == '▀' || r == '█', r == '▄' || r == '█'
+			top, bottom = append(top, upper == invert), append(bottom, lower == invert)
+		}
+		rows = append(rows, top, bottom)
+	}
+	size := n - 2*qrQuietZone
+	m := make([][]bool, size)
+	for y := range m {
+		m[y] = rows[y+qrQuietZone][qrQuietZone : qrQuietZone+size]
+	}
+	return m
+}
+
+// decodeQRMatrix reads a symbol's byte mode data, version and level.
+func decodeQRMatrix(m [][]bool) ([]byte, int, qrLevel, error) {
+	size := len(m)
+	version := (size - 17) / 4
+	if size < 21 || (size-17)%4 != 0 || version > 40 {
+		return nil, 0, 0, fmt.Errorf("%d modules is not a symbol size", size)
+	}
+	bit := func(x, y int) int {
+		if m[y][x] {
+			return 1
+		}
+		return 0
+	}
+
+	// Format information, in both copies
+	var first, second int
+	for i, xy := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		first |= bit(xy[0], xy[1]) << i
+	}
+	for i := 0; i < 8; i++ {
+		second |= bit(size-1-i, 8) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= bit(8, size-15+i) << i
+	}
+	format, ok := qrFormatTable[first]
+	if !ok || first != second {
+		return nil, 0, 0, fmt.Errorf("format information %015b/%015b is not valid", first, second)
+	}
+	if !m[size-8][8] {
+		return nil, 0, 0, errors.New("dark module missing")
+	}
+
+	// Version information, in both copies
+	if version >= 7 {
+		var topRight, bottomLeft int
+		for i := 0; i < 18; i++ {
+			topRight |= bit(size-11+i%3, i/3) << i
+			bottomLeft |= bit(i/3, size-11+i%3) << i
+		}
+		if topRight != bottomLeft || topRight>>12 != version {
+			return nil, 0, 0, fmt.Errorf("version information %x/%x for version %d", topRight, bottomLeft, version)
+		}
+		if want, ok := qrVersionTable[version]; ok && topRight != want {
+			return nil, 0, 0, fmt.Errorf("version %d information %05x, want %05x", version, topRight, want)
+		}
+	}
+
+	// Modules outside the function patterns carry data
+	function := make([][]bool, size)
+	for y := range function {
+		function[y] = make([]bool, size)
+		for x := range function[y] {
+			function[y][x] = x == 6 || y == 6 ||
+				(x < 9 && y < 9) || (x >= size-8 && y < 9) || (x < 9 && y >= size-8) ||
+				(version >= 7 && ((x >= size-11 && x < size-8 && y < 6) || (y >= size-11 && y < size-8 && x < 6)))
+		}
+	}
+	align := qrAlignmentTable[version]
+	if align == nil && version > 1 {
+		align = qrAlignmentPositions(version)
+	}
+	for i, ay := range align {
+		for j, ax := range align {
+			if (i == 0 && j == 0) || (i == 0 && j == len(align)-1) || (i == len(align)-1 && j == 0) {
+				continue
+			}
+			for y := ay - 2; y <= ay+2; y++ {
+				for x := ax - 2; x <= ax+2; x++ {
+					function[y][x] = true
+				}
+			}
+		}
+	}
+
+	masks := [8]func(x, y int) bool{
+		func(x, y int) bool { return (y+x)%2 == 0 },
+		func(x, y int) bool { return y%2 == 0 },
+		func(x, y int) bool { return x%3 == 0 },
+		func(x, y int) bool { return (y+x)%3 == 0 },
+		func(x, y int) bool { return (y/2+x/3)%2 == 0 },
+		func(x, y int) bool { return (y*x)%2+(y*x)%3 == 0 },
+		func(x, y int) bool { return ((y*x)%2+(y*x)%3)%2 == 0 },
+		func(x, y int) bool { return ((y+x)%2+(y*x)%3)%2 == 0 },
+	}
+	mask := masks[format.mask]
+
+	// Zigzag through column pairs from the right, alternating up and down
+	var codewords []byte
+	var cur byte
+	nbits := 0
+	upward := true
+	for right := size - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for k := 0; k < size; k++ {
+			y := k
+			if upward {
+				y = size - 1 - k
+			}
+			for _, x := range []int{right, right - 1} {
+				if function[y][x] {
+					continue
+				}
+				cur = cur<<1 | byte(bit(x, y))
+				if mask(x, y) {
+					cur ^= 1
+				}
+				if nbits++; nbits%8 == 0 {
+					codewords = append(codewords, cur)
+					cur = 0
+				}
+			}
+		}
+		upward = !upward
+	}
+
+	// De-interleave and check each block's Reed-Solomon syndromes
+	numBlocks, eccLen := qrBlocks[format.level][version], qrECCPerBlock[format.level][version]
+	total := len(codewords)
+	shortLen := total / numBlocks
+	numShort := numBlocks - total%numBlocks
+	blocks := make([][]byte, numBlocks)
+	dataLen := func(j int) int {
+		if j < numShort {
+			return shortLen - eccLen
+		}
+		return shortLen - eccLen + 1
+	}
+	k := 0
+	for i := 0; i <= shortLen-eccLen; i++ {
+		for j := range blocks {
+			if i < dataLen(j) {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[k])
+			k++
+		}
+	}
+	var data []byte
+	for j, block := range blocks {
+		for s := 0; s < eccLen; s++ {
+			if syndrome := gfEvaluate(block, gfPow(s)); syndrome != 0 {
+				return nil, 0, 0, fmt.Errorf("block %d: syndrome %d is %d", j, s, syndrome)
+			}
+		}
+		data = append(data, block[:dataLen(j)]...)
+	}
+
+	// One byte mode segment, a terminator and the 0xEC 0x11 padding
+	pos := 0
+	read := func(n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v = v<<1 | int(data[pos/8]>>(7-pos%8)&1)
+			pos++
+		}
+		return v
+	}
+	if mode := read(4); mode != 0b0100 {
+		return nil, 0, 0, fmt.Errorf("mode %04b, want byte mode", mode)
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	n := read(countBits)
+	if pos+8*n > 8*len(data) {
+		return nil, 0, 0, fmt.Errorf("length %d does not fit in %d codewords", n, len(data))
+	}
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(read(8))
+	}
+	for i := 0; i < 4 && pos < 8*len(data); i++ {
+		if read(1) != 0 {
+			return nil, 0, 0, errors.New("terminator is not zero")
+		}
+	}
+	for pos%8 != 0 {
+		if read(1) != 0 {
+			return nil, 0, 0, errors.New("bit padding is not zero")
+		}
+	}
+	for pad := byte(0xEC); pos < 8*len(data); pad ^= 0xEC ^ 0x11 {
+		if b := byte(read(8)); b != pad {
+			return nil, 0, 0, fmt.Errorf("pad codeword %#x, want %#x", b, pad)
+		}
+	}
+	return out, version, format.level, nil
+}
+
+// gfPow returns α^n in GF(2^8) with the QR code polynomial 0x11D.
+func gfPow(n int) byte {
+	x := 1
+	for i := 0; i < n; i++ {
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	return byte(x)
+}
+
+// gfEvaluate evaluates a polynomial, highest coefficient first, at x.
+func gfEvaluate(poly []byte, x byte) byte {
+	var y byte
+	for _, c := range poly {
+		y = gfMultiply(y, x) ^ c
+	}
+	return y
+}
+
+func gfMultiply(a, b byte) byte {
+	var p byte
+	for ; b != 0; b >>= 1 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a&0x80 != 0
+		a <<= 1
+		if carry {
+			a ^= 0x1D
+		}
+	}
+	return p
+}
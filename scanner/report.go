@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"strconv"
+	"time"
+)
+
+// reportRow is one Specs field in the HTML report.
+type reportRow struct {
+	Label string
+	Value string
+	Provenance
+	Detected bool
+	Withheld bool
+}
+
+// reportWarning is one warning in the HTML report.
+type reportWarning struct {
+	Severity Severity
+	Message  string
+	Remedy   string
+}
+
+type reportData struct {
+	Generated time.Time
+	Scanner   string
+	Site      string
+	Rows      []reportRow
+	Warnings  []reportWarning
+	Code      string
+	Link      string
+	QR        template.HTML
+}
+
+// newReportData prepares result for the report. The report is meant to be
+// passed on, so the privacy policy's redactions apply as for a code.
+func newReportData(result DetectionResult, now time.Time) reportData {
+	redacted := privacy.apply(result)
+	specs := redacted.Specs
+	values := map[Component]string{
+		ComponentOS:  specs.OS,
+		ComponentCPU: specs.CPU,
+		ComponentGPU: specs.GPU,
+	}
+	if specs.CPUCores > 0 {
+		values[ComponentCPUCores] = strconv.Itoa(specs.CPUCores)
+	}
+	if specs.CPUSpeedGHz > 0 {
+		values[ComponentCPUSpeed] = fmt.Sprintf("%.1f GHz", specs.CPUSpeedGHz)
+	}
+	if specs.RAMGB > 0 {
+		values[ComponentRAM] = fmt.Sprintf("%d GB", specs.RAMGB)
+	}
+	if specs.StorageGB > 0 {
+		values[ComponentStorage] = fmt.Sprintf("%d GB free", specs.StorageGB)
+	}
+	labels := map[Component]string{
+		ComponentOS:       "Operating system",
+		ComponentCPU:      "Processor",
+		ComponentCPUCores: "CPU cores",
+		ComponentCPUSpeed: "CPU speed",
+		ComponentGPU:      "Graphics",
+		ComponentRAM:      "Memory",
+		ComponentStorage:  "Storage",
+	}
+
+	data := reportData{
+		Generated: now,
+		Scanner:   version,
+		Site:      baseURL,
+		Code:      encodeResult(result),
+		Link:      getURL(result),
+	}
+	for _, c := range components {
+		prov, ok := redacted.Provenance[c]
+		mode := privacy.Redact[c]
+		data.Rows = append(data.Rows, reportRow{
+			Label:      labels[c],
+			Value:      values[c],
+			Provenance: prov,
+			Detected:   ok,
+			Withheld:   mode != "" && mode != redactFull,
+		})
+	}
+	locale := userLocale()
+	for _, w := range redacted.Warnings {
+		data.Warnings = append(data.Warnings, reportWarning{Severity: w.Severity, Message: w.Message(locale), Remedy: w.Remedy(locale)})
+	}
+	// Long links can exceed what a QR code holds; the code still works
+	if q, err := encodeQR([]byte(data.Link)); err == nil {
+		data.QR = template.HTML(q.svg())
+	}
+	return data
+}
+
+// writeReport saves result as a self-contained HTML page that can be
+// archived or emailed and imported on the website later.
+func writeReport(path string, result DetectionResult) error {
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, newReportData(result, time.Now())); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Hardware report – {{.Generated.Format "2006-01-02 15:04"}}</title>
+<style>
+body { font-family: system-ui, -apple-system, "Segoe UI", sans-serif; max-width: 52rem; margin: 2rem auto; padding: 0 1rem; color: #1a1a1a; background: #fff; }
+h1 { font-size: 1.5rem; margin-bottom: 0.25rem; }
+h2 { font-size: 1.1rem; margin-top: 2rem; border-bottom: 1px solid #ddd; padding-bottom: 0.25rem; }
+.meta { color: #666; font-size: 0.9rem; margin-top: 0; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid #eee; vertical-align: top; }
+th { font-weight: 600; color: #444; }
+td.how { color: #666; font-size: 0.85rem; }
+.estimated { color: #a15c00; }
+.missing { color: #999; font-style: italic; }
+ul.warnings { padding-left: 1.2rem; }
+ul.warnings li { margin-bottom: 0.5rem; }
+.severity { font-size: 0.75rem; text-transform: uppercase; font-weight: 600; padding: 0.05rem 0.35rem; border-radius: 3px; background: #eee; }
+.severity.error { background: #fdd; color: #900; }
+.severity.warning { background: #fec; color: #840; }
+.remedy { display: block; color: #555; font-size: 0.9rem; }
+textarea { width: 100%; box-sizing: border-box; height: 7rem; font-family: ui-monospace, monospace; font-size: 0.8rem; word-break: break-all; }
+.share { display: flex; gap: 1.5rem; align-items: flex-start; flex-wrap: wrap; }
+.share > div { flex: 1 1 20rem; }
+.qr svg { width: 12rem; height: 12rem; }
+button { font: inherit; padding: 0.3rem 0.8rem; }
+@media print { button { display: none; } }
+</style>
+</head>
+<body>
+<h1>Hardware report</h1>
+<p class="meta">Generated {{.Generated.Format "2006-01-02 15:04 MST"}} by DoINeedAnUpgrade scanner {{.Scanner}}</p>
+
+<h2>Specs</h2>
+<table>
+<tr><th>Component</th><th>Detected</th><th>How</th></tr>
+{{- range .Rows}}
+<tr>
+<td>{{.Label}}</td>
+{{- if .Value}}
+<td{{if eq .Confidence "estimated"}} class="estimated"{{end}}>{{.Value}}</td>
+{{- else if .Withheld}}
+<td class="missing">withheld</td>
+{{- else}}
+<td class="missing">not detected</td>
+{{- end}}
+<td class="how">{{if .Withheld}}redacted{{else if .Detected}}{{.Detector}} ({{.Source}}, {{.Confidence}}){{end}}</td>
+</tr>
+{{- end}}
+</table>
+
+{{- if .Warnings}}
+<h2>Warnings</h2>
+<ul class="warnings">
+{{- range .Warnings}}
+<li><span class="severity {{.Severity}}">{{.Severity}}</span> {{.Message}}{{if .Remedy}}<span class="remedy">{{.Remedy}}</span>{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+
+<h2>Import on the website</h2>
+<div class="share">
+<div>
+<p>Paste this code on <a href="{{.Site}}">{{.Site}}</a>:</p>
+<textarea id="code" readonly onclick="this.select()">{{.Code}}</textarea>
+<p><button type="button" onclick="copyCode()">Copy code</button></p>
+<p>Or open <a href="{{.Link}}">this link</a> on a device with internet access.</p>
+</div>
+{{- if .QR}}
+<div class="qr">{{.QR}}</div>
+{{- end}}
+</div>
+<script>
+function copyCode() {
+  var code = document.getElementById("code");
+  code.select();
+  if (navigator.clipboard) { navigator.clipboard.writeText(code.value); } else { document.execCommand("copy"); }
+}
+</script>
+</body>
+</html>
+`))
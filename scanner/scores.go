@@ -0,0 +1,198 @@
+package main
+
+import (
+	_ "embed"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// scoresTable is the website's cpuScores and gpuScores as "kind\tname\tscore"
+// lines, regenerated with `make scores`.
+//
+//go:embed scores.tsv
+var scoresTable string
+
+// scoreTable maps known hardware names to the website's relative scores.
+type scoreTable struct {
+	names  []string
+	scores map[string]float64
+}
+
+var cpuScores, gpuScores = loadScores()
+
+func loadScores() (cpu, gpu scoreTable) {
+	cpu.scores = make(map[string]float64)
+	gpu.scores = make(map[string]float64)
+	for _, line := range strings.Split(scoresTable, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		score, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			continue
+		}
+		t := &cpu
+		if fields[0] == "gpu" {
+			t = &gpu
+		}
+		t.names = append(t.names, fields[1])
+		t.scores[fields[1]] = score
+	}
+	return cpu, gpu
+}
+
+// lookup returns the score of the best-matching known name.
+func (t scoreTable) lookup(name string) (match string, score float64, ok bool) {
+	match = fuzzyMatchHardware(name, t.names)
+	if match == "" {
+		return "", 0, false
+	}
+	return match, t.scores[match], true
+}
+
+// The matcher below follows fuzzyMatchHardware in src/lib/fuzzyMatch.ts so
+// the scanner scores hardware the same way the website does.
+
+var matchNoiseWords = map[string]bool{
+	"angle": true, "opengl": true, "direct3d11": true, "direct3d12": true, "d3d11": true, "d3d12": true,
+	"vulkan": true, "metal": true, "google": true, "inc": true, "corporation": true, "technologies": true,
+	"vs_4_0": true, "ps_4_0": true, "vs_5_0": true, "ps_5_0": true, "vs_6_0": true, "ps_6_0": true,
+	"equivalent": true, "better": true, "compatible": true, "above": true, "later": true, "with": true,
+	"or": true, "and": true, "up": true, "series": true,
+	"ghz": true, "mhz": true, "processor": true, "graphics": true, "card": true,
+}
+
+var (
+	matchOrSplit   = regexp.MustCompile(`(?i)\bor\b`)
+	matchClockRe   = regexp.MustCompile(`(?i)@?\s*\d+(\.\d+)?\s*(GHz|MHz)`)
+	matchCoresRe   = regexp.MustCompile(`(?i)\d+\s*-?\s*cores?\b`)
+	matchThreadsRe = regexp.MustCompile(`(?i)\d+\s*-?\s*threads?\b`)
+	matchTokenSep  = regexp.MustCompile(`[\s\-/,@()]+`)
+)
+
+// fuzzyMatchHardware returns the candidate that best matches input, or "" if
+// none scores at least 0.6.
+func fuzzyMatchHardware(input string, candidates []string) string {
+	if strings.TrimSpace(input) == "" {
+		return ""
+	}
+	best, bestNorm, bestRaw := "", 0.0, 0
+	for _, part := range matchOrSplit.Split(input, -1) {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		candidate, norm, raw := matchPart(part, candidates)
+		if norm >= 0.6 && (norm > bestNorm || (norm == bestNorm && raw > bestRaw)) {
+			best, bestNorm, bestRaw = candidate, norm, raw
+		}
+	}
+	return best
+}
+
+func matchPart(input string, candidates []string) (string, float64, int) {
+	cleaned := matchClockRe.ReplaceAllString(input, "")
+	cleaned = matchCoresRe.ReplaceAllString(cleaned, "")
+	cleaned = matchThreadsRe.ReplaceAllString(cleaned, "")
+	all := matchTokens(strings.TrimSpace(cleaned))
+	hasSeries := false
+	var tokens []string
+	for _, t := range all {
+		hasSeries = hasSeries || t == "series"
+		if !matchNoiseWords[t] {
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) == 0 {
+		return "", 0, 0
+	}
+
+	best, bestScore, bestRaw := "", 0.0, 0
+	for _, candidate := range candidates {
+		score, maxPossible := 0, 0
+		for _, ct := range matchTokens(candidate) {
+			numeric := isNumericToken(ct)
+			weight := 1
+			if numeric {
+				weight = 3
+			}
+			maxPossible += weight
+			for _, it := range tokens {
+				var hit bool
+				if numeric {
+					hit = matchNumericToken(it, ct, hasSeries)
+				} else {
+					hit = it == ct || strings.Contains(it, ct) || strings.Contains(ct, it)
+				}
+				if hit {
+					score += weight
+					break
+				}
+			}
+		}
+		normalized := 0.0
+		if maxPossible > 0 {
+			normalized = float64(score) / float64(maxPossible)
+		}
+		if normalized > bestScore || (normalized == bestScore && score > bestRaw) {
+			best, bestScore, bestRaw = candidate, normalized, score
+		}
+	}
+	return best, bestScore, bestRaw
+}
+
+// matchNumericToken lets a round hundred in "600 series" match 650, 660, ...
+func matchNumericToken(input, candidate string, hasSeries bool) bool {
+	if input == candidate {
+		return true
+	}
+	if hasSeries && isRoundHundred(input) {
+		in, err1 := strconv.Atoi(input)
+		cand, err2 := leadingInt(candidate)
+		if err1 == nil && err2 == nil {
+			return abs(cand/100-in/100) <= 1
+		}
+	}
+	return false
+}
+
+func isRoundHundred(token string) bool {
+	n, err := strconv.Atoi(token)
+	return err == nil && n >= 100 && n%100 == 0 && strconv.Itoa(n) == token
+}
+
+// leadingInt parses the digits at the start of s, like JavaScript's parseInt.
+func leadingInt(s string) (int, error) {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	return strconv.Atoi(s[:end])
+}
+
+// matchTokens lowercases and splits text, also splitting digit→letter
+// boundaries so "6GB" becomes "6", "gb" and "2400G" becomes "2400", "g".
+func matchTokens(text string) []string {
+	text = strings.ToLower(text)
+	text = strings.NewReplacer("®", "", "™", "", "©", "").Replace(text)
+	var tokens []string
+	for _, word := range matchTokenSep.Split(text, -1) {
+		start := 0
+		for i := 1; i < len(word); i++ {
+			if unicode.IsDigit(rune(word[i-1])) && word[i] >= 'a' && word[i] <= 'z' {
+				tokens = append(tokens, word[start:i])
+				start = i
+			}
+		}
+		if start < len(word) {
+			tokens = append(tokens, word[start:])
+		}
+	}
+	return tokens
+}
+
+func isNumericToken(token string) bool {
+	return strings.IndexFunc(token, unicode.IsDigit) >= 0
+}
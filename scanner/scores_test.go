@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+// The expected matches below are what fuzzyMatchHardware in
+// src/lib/fuzzyMatch.ts returns for the same inputs and the same cpuScores
+// and gpuScores names, so a drift between the scanner and the website fails
+// here.
+func TestFuzzyMatchHardwareMatchesWebsite(t *testing.T) {
+	tests := []struct {
+		table scoreTable
+		input string
+		want  string
+	}{
+		{cpuScores, "AMD Ryzen 7 5800X 8-Core Processor", "AMD Ryzen 7 5800X"},
+		{cpuScores, "Intel(R) Core(TM) i5-12400F", "Intel Core i5-12400F"},
+		{cpuScores, "12th Gen Intel(R) Core(TM) i7-12700H", "Intel Core i7-12700H"},
+		{cpuScores, "Intel Core i5-6600K or AMD Ryzen 5 2400G", "Intel Core i5-6600K"},
+		{cpuScores, "Intel Core i7-4770 @ 3.40GHz", "Intel Core i7-4770"},
+		{cpuScores, "AMD Ryzen 5 3600 6-Core Processor 12 threads", "AMD Ryzen 5 3600"},
+		{cpuScores, "Apple M2 Pro", "Apple M2 Pro"},
+		{cpuScores, "Intel Core i3-2100 3.1 GHz or better", "Intel Core i3-2100"},
+		{cpuScores, "AMD FX-8350", "AMD FX-8350"},
+		{cpuScores, "Intel Pentium Gold G7400", "Intel Pentium Gold G7400"},
+		{cpuScores, "", ""},
+		{cpuScores, "   ", ""},
+		{cpuScores, "Quad-core processor", ""},
+		{cpuScores, "Intel Core i9-13900K", "Intel Core i9-13900K"},
+		{cpuScores, "AMD Ryzen 9 7950X3D 16-Core Processor", "AMD Ryzen 9 7950X3D"},
+		{cpuScores, "Intel Core Ultra 7 155H", "Intel Core Ultra 7 155H"},
+		{gpuScores, "NVIDIA GeForce RTX 3070", "NVIDIA GeForce RTX 3070"},
+		{gpuScores, "NVIDIA GeForce GTX 1060 6GB", "NVIDIA GeForce GTX 1060 6GB"},
+		{gpuScores, "GeForce GTX 600 series", "NVIDIA GeForce GT 710"},
+		{gpuScores, "NVIDIA GeForce GTX 900 series or AMD Radeon R9 series", "NVIDIA GeForce GT 1030"},
+		{gpuScores, "ANGLE (NVIDIA, NVIDIA GeForce RTX 4090 Direct3D11 vs_5_0 ps_5_0, D3D11)", "NVIDIA GeForce RTX 4090"},
+		{gpuScores, "AMD Radeon RX 6600 XT", "AMD Radeon RX 6600 XT"},
+		{gpuScores, "AMD Radeon RX 580 (8 GB)", "AMD Radeon RX 580"},
+		{gpuScores, "Intel(R) UHD Graphics 630", "Intel HD Graphics 630"},
+		{gpuScores, "Intel Iris Xe Graphics", "Intel Iris Xe Graphics"},
+		{gpuScores, "Apple M1", "Apple M1 GPU"},
+		{gpuScores, "Radeon RX 7900 XTX", "AMD Radeon RX 7900 XT"},
+		{gpuScores, "NVIDIA GeForce RTX 4060 Ti", "NVIDIA GeForce RTX 4060 Ti"},
+		{gpuScores, "DirectX 11 compatible graphics card", ""},
+		{gpuScores, "NVIDIA GeForce GTX 970 or AMD Radeon R9 290", "AMD Radeon R9 290"},
+		{gpuScores, "GTX 1660 Super", "NVIDIA GeForce GTX 1660 Super"},
+		{gpuScores, "Matrox G200eR2", ""},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatchHardware(tt.input, tt.table.names); got != tt.want {
+			t.Errorf("fuzzyMatchHardware(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
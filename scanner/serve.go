@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// scanCache runs detection at most once per ttl so polling clients do not
+// keep spawning lspci and PowerShell. Cache refreshes are not new scans worth
+// keeping, so they are never saved to the history.
+type scanCache struct {
+	flags *scanFlags
+	ttl   time.Duration
+
+	mu     sync.Mutex
+	result DetectionResult
+	at     time.Time
+}
+
+func (c *scanCache) get() (DetectionResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.at.IsZero() && time.Since(c.at) < c.ttl {
+		return c.result, nil
+	}
+	result, err := c.flags.run()
+	if err != nil {
+		return result, err
+	}
+	c.result, c.at = result, time.Now()
+	return result, nil
+}
+
+// runServe exposes the detected specs on localhost for lab dashboards:
+// GET /specs returns the DetectionResult as JSON and GET /code the DINAU code.
+// Both apply the privacy policy's redactions, since --addr can make them
+// reachable from other machines.
+func runServe(args []string) int {
+	fs := newFlagSet("serve")
+	var sf scanFlags
+	sf.register(fs)
+	addr := fs.String("addr", "127.0.0.1:8765", "address to listen on")
+	ttl := fs.Duration("cache", 30*time.Second, "how long a scan result is reused")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	// The capture archive would be rewritten on every request
+	if sf.capture != "" {
+		fmt.Fprintln(os.Stderr, "--capture is not supported by serve")
+		return exitUsage
+	}
+	cache := &scanCache{flags: &sf, ttl: *ttl}
+	server := &http.Server{Addr: *addr, Handler: serveMux(cache), ReadHeaderTimeout: 5 * time.Second}
+	fmt.Fprintf(os.Stderr, "Serving specs on http://%s/specs\n", *addr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// serveMux routes the serve endpoints to results from cache.
+func serveMux(cache *scanCache) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/specs", func(w http.ResponseWriter, r *http.Request) {
+		result, err := cache.get()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(privacy.apply(result))
+	})
+	mux.HandleFunc("/code", func(w http.ResponseWriter, r *http.Request) {
+		result, err := cache.get()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, encodeResult(result))
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
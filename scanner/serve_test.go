@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeRedacts(t *testing.T) {
+	saved := privacy
+	t.Cleanup(func() { privacy = saved })
+	var err error
+	if privacy, err = resolvePrivacy("os=family,gpu=omit,storage=bucket", false, Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := DetectionResult{
+		Specs: Specs{OS: "Ubuntu 24.04.1 LTS", CPU: "AMD Ryzen 7 5800X", CPUCores: 8, GPU: "NVIDIA GeForce RTX 3070", RAMGB: 32, StorageGB: 700},
+		GPUs:  []GPU{{Name: "NVIDIA GeForce RTX 3070", Device: "GA104 [GeForce RTX 3070]", PCIID: "10de:2484", Discrete: true}},
+		Provenance: map[Component]Provenance{
+			ComponentOS:  {Detector: "os-release", Source: SourceFile, Confidence: ConfidenceExact},
+			ComponentGPU: {Detector: "sysfs-pci", Source: SourceSysfs, Confidence: ConfidenceExact},
+		},
+	}
+	srv := httptest.NewServer(serveMux(&scanCache{ttl: time.Hour, result: result, at: time.Now()}))
+	defer srv.Close()
+
+	get := func(path string) string {
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	specs := get("/specs")
+	for _, leaked := range []string{"Ubuntu", "RTX", "GA104", "10de:2484", "sysfs-pci", "os-release"} {
+		if strings.Contains(specs, leaked) {
+			t.Errorf("/specs contains %q:\n%s", leaked, specs)
+		}
+	}
+	var served DetectionResult
+	if err := json.Unmarshal([]byte(specs), &served); err != nil {
+		t.Fatal(err)
+	}
+	if served.Specs.OS != "Linux" || served.Specs.StorageGB != 512 || served.Specs.CPU != result.Specs.CPU {
+		t.Errorf("/specs served %+v, want the redacted specs", served.Specs)
+	}
+
+	p, err := decodePayload(strings.TrimSpace(get("/code")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Specs.OS != "Linux" || p.Specs.GPU != "" {
+		t.Errorf("/code holds %+v, want the redacted specs", p.Specs)
+	}
+}
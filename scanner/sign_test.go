@@ -0,0 +1,198 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withSigner signs payloads with a fresh key for the rest of the test and
+// returns its public key.
+func withSigner(t *testing.T) ed25519.PublicKey {
+	t.Helper()
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := signer
+	signer = key
+	t.Cleanup(func() { signer = saved })
+	return pub
+}
+
+func verifyCode(t *testing.T, code string, pub ed25519.PublicKey) error {
+	t.Helper()
+	p, err := decodePayload(code)
+	if err != nil {
+		t.Fatalf("decode %s: %v", code, err)
+	}
+	_, err = p.verifySignature(pub)
+	return err
+}
+
+func verifyLink(t *testing.T, link string, pub ed25519.PublicKey) error {
+	t.Helper()
+	_, param, ok := strings.Cut(link, "?specs=")
+	if !ok {
+		t.Fatalf("%s has no ?specs= parameter", link)
+	}
+	p, err := decodeSpecsParam(param)
+	if err != nil {
+		t.Fatalf("decode %s: %v", link, err)
+	}
+	_, err = p.verifySignature(pub)
+	return err
+}
+
+func TestSignLiveDetectionOnly(t *testing.T) {
+	pub := withSigner(t)
+	specs := Specs{OS: "Windows 11", CPU: "AMD Ryzen 9 7950X", CPUCores: 16, GPU: "NVIDIA GeForce RTX 4090", RAMGB: 128}
+
+	live := DetectionResult{Specs: specs, live: true}
+	if err := verifyCode(t, encodeResult(live), pub); err != nil {
+		t.Errorf("live detection code: %v, want a valid signature", err)
+	}
+	if err := verifyLink(t, getURL(live), pub); err != nil {
+		t.Errorf("live detection link: %v, want a valid signature", err)
+	}
+
+	// What encode prints for --output code and --output url
+	if err := verifyCode(t, encodeSpecs(specs), pub); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("encode code: %v, want %v", err, ErrUnsigned)
+	}
+	if err := verifyLink(t, getURL(DetectionResult{Specs: specs}), pub); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("encode --url link: %v, want %v", err, ErrUnsigned)
+	}
+
+	sf := scanFlags{replay: filepath.Join("testdata", "fixtures", "ryzen-desktop"), timeout: defaultDetectOptions.Timeout, probeTimeout: defaultDetectOptions.ProbeTimeout}
+	replayed, err := sf.run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyCode(t, encodeResult(replayed), pub); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("--replay code: %v, want %v", err, ErrUnsigned)
+	}
+	if err := verifyLink(t, getURL(replayed), pub); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("--replay link: %v, want %v", err, ErrUnsigned)
+	}
+}
+
+// signedAt encodes result as a code signed by key at the given time.
+func signedAt(t *testing.T, result DetectionResult, key ed25519.PrivateKey, at time.Time) string {
+	t.Helper()
+	p := newPayload(result)
+	p.Version, p.Scanner = payloadVersion, version
+	if err := p.sign(key, at); err != nil {
+		t.Fatal(err)
+	}
+	// Already signed: keep marshal from signing again
+	p.fromScan = false
+	return encodePayload(p)
+}
+
+func TestVerify(t *testing.T) {
+	pub := withSigner(t)
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := DetectionResult{Specs: Specs{OS: "Windows 11", CPU: "Intel Core i5-12400F", CPUCores: 6, GPU: "NVIDIA GeForce RTX 3060", RAMGB: 16, StorageGB: 512}, live: true}
+	good := encodeResult(live)
+
+	p, err := decodePayload(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Specs.RAMGB = 64
+	tampered := encodePayload(p)
+
+	key := base64.StdEncoding.EncodeToString(pub)
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"valid", []string{"--key", key, good}, exitOK},
+		{"build key", []string{good}, exitOK},
+		{"tampered field", []string{"--key", key, tampered}, exitFailure},
+		{"wrong key", []string{"--key", base64.StdEncoding.EncodeToString(otherPub), good}, exitFailure},
+		{"unsigned", []string{"--key", key, encodeSpecs(live.Specs)}, exitFailure},
+		{"within max age", []string{"--key", key, "--max-age", "24h", signedAt(t, live, signer, time.Now().Add(-time.Hour))}, exitOK},
+		{"past max age", []string{"--key", key, "--max-age", "24h", signedAt(t, live, signer, time.Now().Add(-25*time.Hour))}, exitFailure},
+		{"in the future", []string{"--key", key, signedAt(t, live, signer, time.Now().Add(time.Hour))}, exitFailure},
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	for _, tt := range tests {
+		if got := runVerify(tt.args); got != tt.want {
+			t.Errorf("%s: verify exited %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	// The reasons behind the exit codes
+	if err := verifyCode(t, tampered, pub); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered field: %v, want %v", err, ErrBadSignature)
+	}
+	if err := verifyCode(t, good, otherPub); !errors.Is(err, ErrUntrustedKey) {
+		t.Errorf("wrong key: %v, want %v", err, ErrUntrustedKey)
+	}
+	if err := verifyCode(t, encodeSpecs(live.Specs), pub); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("unsigned: %v, want %v", err, ErrUnsigned)
+	}
+}
+
+func TestSignatureAge(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		signedAt time.Time
+		maxAge   time.Duration
+		want     string
+	}{
+		{now.Add(-48 * time.Hour), 0, ""},
+		{now.Add(-time.Hour), 24 * time.Hour, ""},
+		{now.Add(-25 * time.Hour), 24 * time.Hour, "older than 24h0m0s"},
+		{now.Add(time.Minute), 0, ""},
+		{now.Add(time.Hour), 0, "in the future"},
+	}
+	for _, tt := range tests {
+		if _, got := signatureAge(tt.signedAt, now, tt.maxAge); got != tt.want {
+			t.Errorf("signatureAge(%s, max %s) = %q, want %q", now.Sub(tt.signedAt), tt.maxAge, got, tt.want)
+		}
+	}
+}
+
+// TestSignRedacted checks that redacting a live detection keeps it signed.
+func TestSignRedacted(t *testing.T) {
+	pub := withSigner(t)
+	saved := privacy
+	t.Cleanup(func() { privacy = saved })
+	var err error
+	if privacy, err = resolvePrivacy("os=family,ram=bucket,gpu=omit", false, Config{}); err != nil {
+		t.Fatal(err)
+	}
+	live := DetectionResult{Specs: Specs{OS: "Fedora Linux 41", CPU: "AMD Ryzen 5 5600", CPUCores: 6, GPU: "AMD Radeon RX 6600", RAMGB: 31, StorageGB: 1000}, live: true}
+	code := encodeResult(live)
+	if err := verifyCode(t, code, pub); err != nil {
+		t.Errorf("redacted live detection: %v, want a valid signature", err)
+	}
+	p, err := decodePayload(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Specs.OS != "Linux" || p.Specs.GPU != "" || p.Specs.RAMGB != 32 {
+		t.Errorf("signed specs %+v, want the redacted values", p.Specs)
+	}
+}
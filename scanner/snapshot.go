@@ -0,0 +1,335 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A snapshot records every file and command output the Linux detectors
+// consumed. Its layout matches a fixture directory (see
+// testdata/fixtures/README.md), so an extracted snapshot can be checked in as
+// a regression fixture as-is:
+//
+//	root/<path>          files read from the filesystem
+//	commands.json        stdout of each command line
+//	specs.golden.json    the Specs detected when the snapshot was taken
+
+// recordingFS passes reads through to another filesystem and keeps a copy of
+// every file that was read successfully. Directory listings are not kept; the
+// files read below a directory recreate it on replay.
+type recordingFS struct {
+	fs.FS
+	mu      sync.Mutex
+	files   map[string][]byte
+	stopped bool
+}
+
+func (r *recordingFS) ReadFile(name string) ([]byte, error) {
+	data, err := fs.ReadFile(r.FS, name)
+	if err == nil {
+		r.mu.Lock()
+		if !r.stopped {
+			r.files[name] = data
+		}
+		r.mu.Unlock()
+	}
+	return data, err
+}
+
+// stop ends recording and returns the files read so far. Probes abandoned at
+// their deadline may still be running, and their later reads are dropped.
+func (r *recordingFS) stop() map[string][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	return r.files
+}
+
+// recordingRunner runs commands and keeps their output.
+type recordingRunner struct {
+	commandRunner
+	mu       sync.Mutex
+	commands fixtureRunner
+	stopped  bool
+}
+
+func (r *recordingRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := r.commandRunner.Output(ctx, name, args...)
+	rec := recordedOutput{Stdout: string(out)}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	r.mu.Lock()
+	if !r.stopped {
+		r.commands[commandLine(name, args...)] = rec
+	}
+	r.mu.Unlock()
+	return out, err
+}
+
+// stop ends recording and returns the commands run so far, like
+// recordingFS.stop.
+func (r *recordingRunner) stop() fixtureRunner {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	return r.commands
+}
+
+// captureSnapshot runs the Linux detectors against the host while recording
+// their inputs, and writes the snapshot to a .tar.gz archive.
+func captureSnapshot(archivePath string, opts DetectOptions, disabled []string) (DetectionResult, error) {
+	return captureEnv(hostEnv(), archivePath, opts, disabled)
+}
+
+// captureEnv is captureSnapshot for any probe environment.
+func captureEnv(host probeEnv, archivePath string, opts DetectOptions, disabled []string) (DetectionResult, error) {
+	recFS := &recordingFS{FS: host.fs, files: make(map[string][]byte)}
+	runner := &recordingRunner{commandRunner: host.cmd, commands: fixtureRunner{}}
+	env := probeEnv{fs: recFS, cmd: runner}
+
+	registry := NewRegistry(linuxDetectors(env)...)
+	for _, name := range disabled {
+		registry.Disable(name)
+	}
+	result := registry.Run(context.Background(), opts)
+	files, recorded := recFS.stop(), runner.stop()
+
+	commands, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return result, err
+	}
+	golden, err := json.MarshalIndent(result.Specs, "", "  ")
+	if err != nil {
+		return result, err
+	}
+	entries := map[string][]byte{
+		"commands.json":     append(commands, '\n'),
+		"specs.golden.json": append(golden, '\n'),
+	}
+	for name, data := range files {
+		entries[path.Join("root", name)] = data
+	}
+	return result, writeTarGz(archivePath, entries)
+}
+
+func writeTarGz(archivePath string, entries map[string][]byte) error {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	for _, name := range names {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0o644,
+			Size:    int64(len(entries[name])),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(entries[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// snapshotEnv loads a snapshot from a .tar.gz archive or from an extracted
+// fixture directory.
+func snapshotEnv(src string) (probeEnv, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return probeEnv{}, err
+	}
+	if info.IsDir() {
+		return fixtureEnv(src)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return probeEnv{}, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return probeEnv{}, fmt.Errorf("read snapshot: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	root := archiveFS{}
+	runner := fixtureRunner{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return probeEnv{}, fmt.Errorf("read snapshot: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return probeEnv{}, fmt.Errorf("read snapshot: %w", err)
+		}
+		name := strings.TrimPrefix(path.Clean(hdr.Name), "./")
+		switch {
+		case name == "commands.json":
+			if err := json.Unmarshal(data, &runner); err != nil {
+				return probeEnv{}, fmt.Errorf("parse commands.json: %w", err)
+			}
+		case strings.HasPrefix(name, "root/"):
+			root[strings.TrimPrefix(name, "root/")] = data
+		}
+	}
+	return probeEnv{fs: root, cmd: runner}, nil
+}
+
+// archiveFS is a read-only filesystem over the root/ files of a snapshot
+// archive, keyed by slash-separated path. Directories exist implicitly as
+// the parents of those files.
+type archiveFS map[string][]byte
+
+func (a archiveFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if data, ok := a[name]; ok {
+		info := archiveInfo{name: path.Base(name), size: int64(len(data))}
+		return &archiveFile{info: info, Reader: bytes.NewReader(data)}, nil
+	}
+	entries, err := a.ReadDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &archiveDir{info: archiveInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+func (a archiveFS) ReadFile(name string) ([]byte, error) {
+	data, ok := a[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(data), nil
+}
+
+func (a archiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	seen := make(map[string]bool)
+	var entries []fs.DirEntry
+	for file, data := range a {
+		rest, ok := strings.CutPrefix(file, prefix)
+		if !ok {
+			continue
+		}
+		child, _, isDir := strings.Cut(rest, "/")
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		info := archiveInfo{name: child, dir: isDir}
+		if !isDir {
+			info.size = int64(len(data))
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// archiveInfo describes a file or implied directory of an archiveFS.
+type archiveInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i archiveInfo) Name() string       { return i.name }
+func (i archiveInfo) Size() int64        { return i.size }
+func (i archiveInfo) ModTime() time.Time { return time.Time{} }
+func (i archiveInfo) IsDir() bool        { return i.dir }
+func (i archiveInfo) Sys() any           { return nil }
+
+func (i archiveInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+type archiveFile struct {
+	info archiveInfo
+	*bytes.Reader
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *archiveFile) Close() error               { return nil }
+
+type archiveDir struct {
+	info    archiveInfo
+	entries []fs.DirEntry
+}
+
+func (d *archiveDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *archiveDir) Close() error               { return nil }
+
+func (d *archiveDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *archiveDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestArchiveFS(t *testing.T) {
+	root := archiveFS{
+		"etc/os-release":                          []byte("NAME=Fedora\n"),
+		"proc/cpuinfo":                            []byte("processor\t: 0\n"),
+		"sys/bus/pci/devices/0000:01:00.0/class":  []byte("0x030000\n"),
+		"sys/bus/pci/devices/0000:01:00.0/vendor": []byte("0x10de\n"),
+	}
+	if err := fstest.TestFS(root, "etc/os-release", "proc/cpuinfo", "sys/bus/pci/devices/0000:01:00.0/class"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// slowRunner answers every command after a delay, ignoring the probe deadline
+// like a hung lspci.
+type slowRunner struct {
+	commandRunner
+	delay time.Duration
+}
+
+func (s slowRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	time.Sleep(s.delay)
+	return s.commandRunner.Output(ctx, name, args...)
+}
+
+// TestCaptureAbandonedProbes captures while every command outlives the probe
+// timeout. Run with -race: the abandoned probes finish while the snapshot is
+// being written and must not touch the recording.
+func TestCaptureAbandonedProbes(t *testing.T) {
+	fixture, err := fixtureEnv(filepath.Join("testdata", "fixtures", "intel-hybrid-desktop"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow := slowRunner{commandRunner: fixture.cmd, delay: 30 * time.Millisecond}
+	archive := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	opts := DetectOptions{ProbeTimeout: 20 * time.Millisecond, Timeout: time.Second}
+
+	result, err := captureEnv(probeEnv{fs: fixture.fs, cmd: slow}, archive, opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(result.Specs.Warnings, " "), CodeProbeTimeout) {
+		t.Errorf("warnings %v, want a probe timeout", result.Specs.Warnings)
+	}
+	if _, err := snapshotEnv(archive); err != nil {
+		t.Error(err)
+	}
+	// Let the abandoned probes finish inside this test
+	time.Sleep(2 * slow.delay)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far in the future a signature time may be before it is
+// rejected.
+const clockSkew = 5 * time.Minute
+
+// trustedKey returns the public key signatures must match: --key, or the
+// public half of this build's signing key.
+func trustedKey(flagValue string) (ed25519.PublicKey, error) {
+	if flagValue != "" {
+		return parsePublicKey(readKey(flagValue))
+	}
+	if signer != nil {
+		return signer.Public().(ed25519.PublicKey), nil
+	}
+	return nil, ErrNoTrustedKey
+}
+
+// runVerify checks that a code was signed by a trusted scanner build and
+// reports the scanner version and signing time.
+func runVerify(args []string) int {
+	fs := newFlagSet("verify")
+	key := fs.String("key", "", "trusted public key (base64 or a file containing it); defaults to this build's key")
+	maxAge := fs.Duration("max-age", 0, "reject signatures older than this (e.g. 24h); 0 accepts any age")
+	scanners := fs.String("scanner", "", "comma-separated scanner versions to accept; empty accepts any")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	trusted, err := trustedKey(*key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	input := fs.Arg(0)
+	if input == "" || input == "-" {
+		data, err := readInput("")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		input = string(data)
+	}
+	p, err := payloadFromInput(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not decode: %v\n", err)
+		return exitFailure
+	}
+
+	sig, err := p.verifySignature(trusted)
+	if err != nil {
+		if errors.Is(err, ErrUnsigned) && p.Version < payloadVersion {
+			err = fmt.Errorf("%w (version %d payloads cannot carry a signature)", err, p.Version)
+		}
+		fmt.Printf("INVALID: %v\n", err)
+		return exitFailure
+	}
+
+	failed := false
+	fmt.Printf("Signature: valid (key %s)\n", base64.StdEncoding.EncodeToString(trusted))
+	fmt.Printf("Scanner:   %s", p.Scanner)
+	if *scanners != "" && !containsField(*scanners, p.Scanner) {
+		fmt.Printf(" (not an accepted version: %s)", *scanners)
+		failed = true
+	}
+	fmt.Println()
+
+	age, problem := signatureAge(sig.SignedAt, time.Now(), *maxAge)
+	fmt.Printf("Signed at: %s (%s ago)", sig.SignedAt.Local().Format(time.RFC3339), age.Round(time.Second))
+	if problem != "" {
+		fmt.Printf(" (%s)", problem)
+		failed = true
+	}
+	fmt.Println()
+
+	fmt.Println()
+	printResult(os.Stdout, resultFromPayload(p))
+	if failed {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// signatureAge returns how long before now a signature was made and, if it
+// is too old for maxAge or in the future, why it is rejected. A zero maxAge
+// accepts any age.
+func signatureAge(signedAt, now time.Time, maxAge time.Duration) (time.Duration, string) {
+	age := now.Sub(signedAt)
+	switch {
+	case age < -clockSkew:
+		return age, "in the future"
+	case maxAge > 0 && age > maxAge:
+		return age, fmt.Sprintf("older than %s", maxAge)
+	}
+	return age, ""
+}
+
+func containsField(list, value string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// runKeygen prints a new signing key pair for -ldflags or --sign-key.
+func runKeygen(args []string) int {
+	fs := newFlagSet("keygen")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	fmt.Printf("Signing key (keep private, build with SIGN_KEY=...): %s\n", base64.StdEncoding.EncodeToString(priv.Seed()))
+	fmt.Printf("Public key (give to verifiers, verify --key ...):    %s\n", base64.StdEncoding.EncodeToString(pub))
+	return exitOK
+}
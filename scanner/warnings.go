@@ -0,0 +1,196 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// Severity says how much a warning affects the detected Specs.
+type Severity string
+
+const (
+	// SeverityInfo marks a value that was detected in a less precise way.
+	SeverityInfo Severity = "info"
+	// SeverityWarning marks a value that may be wrong or was estimated.
+	SeverityWarning Severity = "warning"
+	// SeverityError marks a value that could not be detected at all.
+	SeverityError Severity = "error"
+)
+
+// Warning codes are stable identifiers that tools built on the scanner can
+// branch on. Their English text lives in the message catalog below.
+const (
+	CodeOSUndetected           = "os.undetected"
+	CodeOSNameUndetected       = "os.name_undetected"
+	CodeCPUNameUndetected      = "cpu.name_undetected"
+	CodeCPUArchUndetected      = "cpu.arch_undetected"
+	CodeCPUCoresUndetected     = "cpu.cores_undetected"
+	CodeCPUCoresLogical        = "cpu.cores_logical"
+	CodeCPUSpeedUndetected     = "cpu.speed_undetected"
+	CodeCPUSpeedEstimated      = "cpu.speed_estimated"
+	CodeCPUSpeedFallback       = "cpu.speed_fallback"
+	CodeGPULspciMissing        = "gpu.lspci_missing"
+	CodeGPUNoDisplayDevice     = "gpu.no_display_device"
+	CodeGPUUnnamedDevice       = "gpu.unnamed_device"
+	CodeGPUUndetected          = "gpu.undetected"
+	CodeGPUPreferenceUnmatched = "gpu.preference_unmatched"
+	CodeRAMUndetected          = "ram.undetected"
+	CodeStorageUndetected      = "storage.undetected"
+	CodeStorageUnparsed        = "storage.unparsed"
+	CodeWMIQueryFailed         = "wmi.query_failed"
+	CodeProbeNotApplicable     = "probe.not_applicable"
+	CodeProbeFailed            = "probe.failed"
+	CodeProbeTimeout           = "probe.timeout"
+	CodeScanTimeout            = "scan.timeout"
+	CodeInvalidProbeReading    = "probe.invalid_reading"
+)
+
+// Warning describes a problem found while detecting one Specs field. It
+// implements error so detectors can return it directly.
+type Warning struct {
+	Code     string    `json:"code"`
+	Severity Severity  `json:"severity"`
+	Field    Component `json:"field,omitempty"`
+	// Cause is the underlying error, if any. It is not localised.
+	Cause string `json:"cause,omitempty"`
+	// Params fill the {placeholders} in the localised message.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+func newWarning(code string, severity Severity, field Component) Warning {
+	return Warning{Code: code, Severity: severity, Field: field}
+}
+
+// withCause attaches the underlying error.
+func (w Warning) withCause(err error) Warning {
+	if err != nil {
+		w.Cause = err.Error()
+	}
+	return w
+}
+
+// with sets a message parameter.
+func (w Warning) with(key, value string) Warning {
+	params := make(map[string]string, len(w.Params)+1)
+	for k, v := range w.Params {
+		params[k] = v
+	}
+	params[key] = value
+	w.Params = params
+	return w
+}
+
+func (w Warning) Error() string {
+	return w.Message(defaultLocale)
+}
+
+// warningText is the localised text for one warning code.
+type warningText struct {
+	Message string
+	Remedy  string
+}
+
+const defaultLocale = "en"
+
+// warningCatalog maps locale to code to text. Locales other than English may
+// omit codes; missing entries fall back to English.
+var warningCatalog = map[string]map[string]warningText{
+	"en": {
+		CodeOSUndetected:           {"Could not detect OS version", ""},
+		CodeOSNameUndetected:       {"Could not detect OS product name", ""},
+		CodeCPUNameUndetected:      {"Could not detect CPU name", "Enter your CPU model on the website."},
+		CodeCPUArchUndetected:      {"Could not detect CPU architecture", ""},
+		CodeCPUCoresUndetected:     {"Could not detect CPU core count", "Enter your core count on the website."},
+		CodeCPUCoresLogical:        {"Core count is logical threads, not physical cores (nproc fallback)", "Check your physical core count on the website."},
+		CodeCPUSpeedUndetected:     {"Could not detect CPU speed", "Enter your CPU's boost clock on the website."},
+		CodeCPUSpeedEstimated:      {"CPU speed for {chip} is estimated ({ghz} GHz)", ""},
+		CodeCPUSpeedFallback:       {"Could not determine Apple Silicon generation, using 3.0 GHz fallback", "Check your CPU speed on the website."},
+		CodeGPULspciMissing:        {"Could not detect GPU (lspci failed)", "Install pciutils (the package providing lspci) and scan again."},
+		CodeGPUNoDisplayDevice:     {"Could not find a display controller on the PCI bus", "Select your GPU on the website."},
+		CodeGPUUndetected:          {"Could not detect GPU name", "Select your GPU on the website."},
+		CodeGPUUnnamedDevice:       {"No name known for PCI device {device}", "Install pciutils (the package providing lspci) and scan again, or select your GPU on the website."},
+		CodeGPUPreferenceUnmatched: {"No GPU matches the preference \"{preference}\", reporting the default choice", "Check --gpu or \"gpu\" in the config file against the GPUs listed by scan."},
+		CodeRAMUndetected:          {"Could not detect RAM size", "Enter your RAM size on the website."},
+		CodeStorageUndetected:      {"Could not detect storage free space", "Enter your free storage on the website."},
+		CodeStorageUnparsed:        {"Could not parse storage free space", "Enter your free storage on the website."},
+		CodeWMIQueryFailed:         {"Failed to read hardware information from PowerShell", "Make sure PowerShell is available and scan again."},
+		CodeProbeNotApplicable:     {"{detector} does not apply to this machine", ""},
+		CodeProbeFailed:            {"{detector} failed", ""},
+		CodeProbeTimeout:           {"{detector} did not respond within {after} ({field} skipped)", "Scan again, or enter the value on the website."},
+		CodeScanTimeout:            {"Scan time limit reached before {field} finished ({detector} skipped)", "Scan again, or enter the value on the website."},
+		CodeInvalidProbeReading:    {"{detector} returned an unusable value for {field}", ""},
+	},
+}
+
+// text returns the catalog entry for the code in the given locale.
+func (w Warning) text(locale string) warningText {
+	if t, ok := warningCatalog[locale][w.Code]; ok {
+		return t
+	}
+	if t, ok := warningCatalog[defaultLocale][w.Code]; ok {
+		return t
+	}
+	return warningText{Message: w.Code}
+}
+
+// Message renders the warning in the given locale.
+func (w Warning) Message(locale string) string {
+	return w.expand(w.text(locale).Message)
+}
+
+// Remedy renders the suggested fix in the given locale, or "" if there is none.
+func (w Warning) Remedy(locale string) string {
+	return w.expand(w.text(locale).Remedy)
+}
+
+func (w Warning) expand(s string) string {
+	if strings.Contains(s, "{field}") {
+		s = strings.ReplaceAll(s, "{field}", string(w.Field))
+	}
+	for k, v := range w.Params {
+		s = strings.ReplaceAll(s, "{"+k+"}", v)
+	}
+	return s
+}
+
+// userLocale picks the catalog locale from the environment, e.g. "de" for
+// LANG=de_DE.UTF-8.
+func userLocale() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(key)
+		if v == "" || v == "C" || v == "POSIX" {
+			continue
+		}
+		parts := strings.FieldsFunc(v, func(r rune) bool {
+			return r == '_' || r == '.' || r == '@' || r == '-'
+		})
+		if len(parts) == 0 {
+			continue
+		}
+		lang := strings.ToLower(parts[0])
+		if _, ok := warningCatalog[lang]; ok {
+			return lang
+		}
+		return defaultLocale
+	}
+	return defaultLocale
+}
+
+// warningMessages renders warnings for display in the user's locale.
+func warningMessages(warnings []Warning) []string {
+	locale := userLocale()
+	msgs := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		msgs = append(msgs, w.Message(locale))
+	}
+	return msgs
+}
+
+// warningCodes lists the codes of warnings, for the encoded payload.
+func warningCodes(warnings []Warning) []string {
+	codes := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		codes = append(codes, w.Code)
+	}
+	return codes
+}
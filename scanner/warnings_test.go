@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+func TestUserLocaleSeparatorsOnly(t *testing.T) {
+	for _, v := range []string{".", "_", "-@.", "en_US.UTF-8"} {
+		t.Setenv("LC_ALL", v)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", "en_GB")
+		if got := userLocale(); got != defaultLocale {
+			t.Errorf("LC_ALL=%q: userLocale() = %q, want %q", v, got, defaultLocale)
+		}
+	}
+}
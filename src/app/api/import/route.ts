@@ -31,6 +31,10 @@ export async function POST(request: NextRequest) {
       gpu: typeof body.gpu === "string" ? body.gpu : "",
       ramGB: typeof body.ramGB === "number" ? body.ramGB : null,
       storageGB: typeof body.storageGB === "number" ? body.storageGB : null,
+      ramApproximate: body.ramApproximate === true,
+      guessedFields: Array.isArray(body.guessedFields)
+        ? body.guessedFields.filter((f: unknown) => typeof f === "string")
+        : undefined,
     };
 
     const token = storeSpecs(specs);
@@ -10,7 +10,7 @@ import { computeVerdict } from "@/lib/computeVerdict";
 import { useBenchmarks } from "@/lib/useBenchmarks";
 import { detectClientSpecs } from "@/lib/detectClientSpecs";
 import { fuzzyMatchHardware } from "@/lib/fuzzyMatch";
-import { decodeSpecsPayload } from "@/lib/decodeSpecsPayload";
+import { decodeSpecsParam, decodeCompressedSpecsParam, isCompressedSpecsParam } from "@/lib/decodeSpecsPayload";
 import { getPendingGame, clearPendingGame } from "@/lib/pendingGameCheck";
 import WizardStepper from "@/components/WizardStepper";
 import StepGameSelect from "@/components/StepGameSelect";
@@ -111,7 +111,8 @@ function Home() {
             ramGB: typeof data.ramGB === "number" ? data.ramGB : null,
             storageGB: typeof data.storageGB === "number" ? data.storageGB : null,
             detectionSource: "script",
-            ramApproximate: false,
+            ramApproximate: data.ramApproximate === true,
+            guessedFields: Array.isArray(data.guessedFields) && data.guessedFields.length > 0 ? data.guessedFields : undefined,
           };
 
           setSpecs(imported);
@@ -121,7 +122,7 @@ function Home() {
           const detected = detectPlatformFromOS(imported.os || "");
           setPlatform(detected);
           setUserPlatform(detected);
-          setUnmatchedFields([]);
+          setUnmatchedFields(imported.guessedFields ?? []);
           setDetecting(false);
           setShowUrlImportToast(true);
 
@@ -171,76 +172,86 @@ function Home() {
     }
 
     // Priority 1: Check URL params for specs (from hardware scanner script)
+    const applyUrlSpecs = (decoded: UserSpecs) => {
+      setSpecs(decoded);
+      const now = new Date().toISOString();
+      setSavedAt(now);
+      localStorage.setItem("savedSpecs", JSON.stringify({ specs: decoded, savedAt: now }));
+      const detected = detectPlatformFromOS(decoded.os || "");
+      setPlatform(detected);
+      setUserPlatform(detected);
+      setUnmatchedFields(decoded.guessedFields ?? []);
+      setDetecting(false);
+      setShowUrlImportToast(true);
+
+      // Clean up URL without reload
+      if (typeof window !== "undefined") {
+        const url = new URL(window.location.href);
+        url.searchParams.delete("specs");
+        window.history.replaceState({}, "", url.pathname);
+      }
+
+      // Check if there's a pending game to restore (downloaded scanner from "Your System" step)
+      const pendingGame = getPendingGame();
+      if (pendingGame) {
+        clearPendingGame();
+        // Fetch game and go directly to results
+        (async () => {
+          try {
+            const res = await fetch(`/api/game?appid=${pendingGame.appid}`);
+            if (!res.ok) throw new Error("Failed to load game details");
+            const data: GameDetails = await res.json();
+            setGame(data);
+
+            // Use pending platform or auto-select based on user's OS
+            const selectedPlatform = data.availablePlatforms.includes(detected)
+              ? detected
+              : data.availablePlatforms[0] ?? "windows";
+            setPlatform(selectedPlatform);
+
+            const platformReqs = data.platformRequirements[selectedPlatform] ?? data.requirements;
+            const newMin = platformReqs.minimum ?? { os: "", cpu: "", gpu: "", ram: "", storage: "" };
+            const newRec = platformReqs.recommended ?? { os: "", cpu: "", gpu: "", ram: "", storage: "" };
+            setMinReqs(newMin);
+            setRecReqs(newRec);
+
+            // Run comparison
+            const hasMin = Object.values(newMin).some((v) => v.trim() !== "");
+            const hasRec = Object.values(newRec).some((v) => v.trim() !== "");
+            const minArg = hasMin ? newMin : null;
+            const recArg = hasRec ? newRec : null;
+            const { items: compItems, scores } = compareSpecs(decoded, minArg, recArg, cpuScores, gpuScores);
+            setComparison(compItems);
+            setHardwareScores(scores);
+            setSpecsConfirmed(true);
+            setSpecsDirty(false);
+            setStep(3);
+            setMaxReached(3);
+            // Normal mode - not scanner mode
+            setImportedFromScanner(false);
+          } catch {
+            // Fall back to scanner mode if game fetch fails
+            setImportedFromScanner(true);
+          }
+        })();
+      } else {
+        // No pending game - use scanner mode
+        setImportedFromScanner(true);
+      }
+    };
+
     const urlSpecs = searchParams.get("specs");
     if (urlSpecs) {
-      // Add DINAU: prefix since URL only contains the base64 part
-      const decoded = decodeSpecsPayload(`DINAU:${urlSpecs}`);
+      if (isCompressedSpecsParam(urlSpecs)) {
+        decodeCompressedSpecsParam(urlSpecs).then((decoded) => {
+          if (decoded) applyUrlSpecs(decoded);
+          else setDetecting(false);
+        });
+        return;
+      }
+      const decoded = decodeSpecsParam(urlSpecs);
       if (decoded) {
-        setSpecs(decoded);
-        const now = new Date().toISOString();
-        setSavedAt(now);
-        localStorage.setItem("savedSpecs", JSON.stringify({ specs: decoded, savedAt: now }));
-        const detected = detectPlatformFromOS(decoded.os || "");
-        setPlatform(detected);
-        setUserPlatform(detected);
-        setUnmatchedFields([]);
-        setDetecting(false);
-        setShowUrlImportToast(true);
-
-        // Clean up URL without reload
-        if (typeof window !== "undefined") {
-          const url = new URL(window.location.href);
-          url.searchParams.delete("specs");
-          window.history.replaceState({}, "", url.pathname);
-        }
-
-        // Check if there's a pending game to restore (downloaded scanner from "Your System" step)
-        const pendingGame = getPendingGame();
-        if (pendingGame) {
-          clearPendingGame();
-          // Fetch game and go directly to results
-          (async () => {
-            try {
-              const res = await fetch(`/api/game?appid=${pendingGame.appid}`);
-              if (!res.ok) throw new Error("Failed to load game details");
-              const data: GameDetails = await res.json();
-              setGame(data);
-
-              // Use pending platform or auto-select based on user's OS
-              const selectedPlatform = data.availablePlatforms.includes(detected)
-                ? detected
-                : data.availablePlatforms[0] ?? "windows";
-              setPlatform(selectedPlatform);
-
-              const platformReqs = data.platformRequirements[selectedPlatform] ?? data.requirements;
-              const newMin = platformReqs.minimum ?? { os: "", cpu: "", gpu: "", ram: "", storage: "" };
-              const newRec = platformReqs.recommended ?? { os: "", cpu: "", gpu: "", ram: "", storage: "" };
-              setMinReqs(newMin);
-              setRecReqs(newRec);
-
-              // Run comparison
-              const hasMin = Object.values(newMin).some((v) => v.trim() !== "");
-              const hasRec = Object.values(newRec).some((v) => v.trim() !== "");
-              const minArg = hasMin ? newMin : null;
-              const recArg = hasRec ? newRec : null;
-              const { items: compItems, scores } = compareSpecs(decoded, minArg, recArg, cpuScores, gpuScores);
-              setComparison(compItems);
-              setHardwareScores(scores);
-              setSpecsConfirmed(true);
-              setSpecsDirty(false);
-              setStep(3);
-              setMaxReached(3);
-              // Normal mode - not scanner mode
-              setImportedFromScanner(false);
-            } catch {
-              // Fall back to scanner mode if game fetch fails
-              setImportedFromScanner(true);
-            }
-          })();
-        } else {
-          // No pending game - use scanner mode
-          setImportedFromScanner(true);
-        }
+        applyUrlSpecs(decoded);
         return;
       }
     }
@@ -15,6 +15,10 @@ export function decodeSpecsPayload(input: string): UserSpecs | null {
     return null;
   }
 
+  return parseSpecsJSON(json);
+}
+
+function parseSpecsJSON(json: string): UserSpecs | null {
   let data: unknown;
   try {
     data = JSON.parse(json);
@@ -22,6 +26,10 @@ export function decodeSpecsPayload(input: string): UserSpecs | null {
     return null;
   }
 
+  return specsFromObject(data);
+}
+
+function specsFromObject(data: unknown): UserSpecs | null {
   if (typeof data !== "object" || data === null) return null;
 
   const obj = data as Record<string, unknown>;
@@ -34,6 +42,9 @@ export function decodeSpecsPayload(input: string): UserSpecs | null {
   const cpuSpeedGHz = typeof obj.cpuSpeedGHz === "number" ? obj.cpuSpeedGHz : null;
   const ramGB = typeof obj.ramGB === "number" ? obj.ramGB : null;
   const storageGB = typeof obj.storageGB === "number" ? obj.storageGB : null;
+  const guessedFields = Array.isArray(obj.guessedFields)
+    ? obj.guessedFields.filter((f): f is string => typeof f === "string")
+    : [];
 
   return {
     os: obj.os,
@@ -44,6 +55,83 @@ export function decodeSpecsPayload(input: string): UserSpecs | null {
     ramGB,
     storageGB,
     detectionSource: "script",
-    ramApproximate: false,
+    ramApproximate: obj.ramApproximate === true,
+    guessedFields: guessedFields.length > 0 ? guessedFields : undefined,
   };
 }
+
+// Compact ?specs= values from the scanner: "c1." + base64url(JSON with short
+// keys), or "z1." + base64url(raw-deflate of that JSON). Untagged values are
+// the legacy standard base64 payload.
+const COMPACT_TAG = "c1.";
+const COMPRESSED_TAG = "z1.";
+
+const COMPACT_KEYS: Record<string, string> = {
+  o: "os",
+  c: "cpu",
+  n: "cpuCores",
+  s: "cpuSpeedGHz",
+  g: "gpu",
+  r: "ramGB",
+  d: "storageGB",
+  w: "warnings",
+  q: "guessedFields",
+  a: "ramApproximate",
+  v: "v",
+  sv: "scanner",
+  k: "sum",
+  x: "ext",
+};
+
+function base64UrlToBytes(value: string): Uint8Array | null {
+  const b64 = value.replace(/-/g, "+").replace(/_/g, "/");
+  try {
+    const binary = atob(b64 + "=".repeat((4 - (b64.length % 4)) % 4));
+    return Uint8Array.from(binary, (c) => c.charCodeAt(0));
+  } catch {
+    return null;
+  }
+}
+
+function parseCompactJSON(bytes: Uint8Array): UserSpecs | null {
+  let data: unknown;
+  try {
+    data = JSON.parse(new TextDecoder().decode(bytes));
+  } catch {
+    return null;
+  }
+  if (typeof data !== "object" || data === null) return null;
+
+  const expanded: Record<string, unknown> = {};
+  for (const [key, value] of Object.entries(data)) {
+    expanded[COMPACT_KEYS[key] ?? key] = value;
+  }
+  return specsFromObject(expanded);
+}
+
+export function isCompressedSpecsParam(param: string): boolean {
+  return param.startsWith(COMPRESSED_TAG);
+}
+
+// Decodes a ?specs= value that does not need decompression.
+export function decodeSpecsParam(param: string): UserSpecs | null {
+  if (param.startsWith(COMPACT_TAG)) {
+    const bytes = base64UrlToBytes(param.slice(COMPACT_TAG.length));
+    return bytes ? parseCompactJSON(bytes) : null;
+  }
+  // URLSearchParams turns "+" in legacy base64 into spaces
+  return decodeSpecsPayload(`${PREFIX}${param.replace(/ /g, "+")}`);
+}
+
+// Decodes a deflate-compressed ?specs= value.
+export async function decodeCompressedSpecsParam(param: string): Promise<UserSpecs | null> {
+  const bytes = base64UrlToBytes(param.slice(COMPRESSED_TAG.length));
+  if (!bytes || typeof DecompressionStream === "undefined") return null;
+  try {
+    const stream = new Blob([bytes.buffer as ArrayBuffer]).stream().pipeThrough(new DecompressionStream("deflate-raw"));
+    const inflated = new Uint8Array(await new Response(stream).arrayBuffer());
+    return parseCompactJSON(inflated);
+  } catch {
+    return null;
+  }
+}